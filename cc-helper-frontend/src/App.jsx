@@ -7,12 +7,13 @@ import {
   useNavigate,
 } from "react-router-dom";
 
-import { fetchMe, setToken } from "./api";
+import { logout, openEventStream, refreshSession } from "./api";
 
 import LoginPage from "./pages/LoginPage";
 import ListPage from "./pages/ListPage";
 import DetailPage from "./pages/DetailPage";
 import SearchPage from "./pages/SearchPage";
+import ChangeDiffPage from "./pages/ChangeDiffPage";
 import AdminEditor from "./pages/AdminEditor";
 import AdminCategoriesPage from "./pages/AdminCategoriesPage";
 import AdminBreakingNewsPage from "./pages/AdminBreakingNewsPage";
@@ -26,17 +27,12 @@ function App() {
   const [user, setUser] = useState(null);
   const [loaded, setLoaded] = useState(false);
 
+  // sesi lama dipulihkan lewat refresh token (access token pendek)
   useEffect(() => {
-    const saved = localStorage.getItem("cc-helper-token");
-    if (saved) {
-      setToken(saved);
-      fetchMe()
-        .then(setUser)
-        .catch(() => {})
-        .finally(() => setLoaded(true));
-    } else {
-      setLoaded(true);
-    }
+    refreshSession()
+      .then((data) => setUser(data.user))
+      .catch(() => {})
+      .finally(() => setLoaded(true));
   }, []);
 
   if (!loaded) return null;
@@ -60,11 +56,28 @@ function MainLayout({ user, setUser }) {
   }, [location]);
 
   function handleLogout() {
-    localStorage.removeItem("cc-helper-token");
-    setUser(null);
-    window.location.reload();
+    logout().finally(() => {
+      setUser(null);
+      window.location.reload();
+    });
   }
 
+  // push realtime: ticker & halaman konten refresh sendiri, logout paksa dari admin
+  useEffect(() => {
+    const refreshTicker = () => window.dispatchEvent(new Event("breaking-news-updated"));
+    return openEventStream({
+      breaking_news: refreshTicker,
+      resync: refreshTicker,
+      product: (data) =>
+        window.dispatchEvent(new CustomEvent("content-updated", { detail: data })),
+      logout: (data) => {
+        if (data && data.reason) alert(data.reason);
+        handleLogout();
+      },
+    });
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
   function onSearchSubmit(e) {
     e.preventDefault();
     const q = search.trim();
@@ -203,6 +216,7 @@ function MainLayout({ user, setUser }) {
                 />
 
                 <Route path="/search" element={<SearchPage />} />
+                <Route path="/changes/:id" element={<ChangeDiffPage />} />
 
                 <Route
                   path="/admin/editor"
@@ -25,7 +25,131 @@ function authHeaders() {
   return h;
 }
 
+// ===================== REALTIME (SSE) =====================
+// GET /events?ticket=... ; tiket sekali pakai diminta dulu lewat
+// POST /events/ticket (JWT tidak ditaruh di URL karena tercatat di log).
+// Karena tiket tidak bisa dipakai ulang, tiap putus stream dibuka ulang di
+// sini dengan tiket baru + lastEventId supaya event yang terlewat disusul.
+// handlers: { breaking_news, product, logout, resync } -> fn(data)
+export function openEventStream(handlers = {}) {
+  let es = null;
+  let retryTimer = null;
+  let closed = false;
+  let lastEventId = "";
+
+  const retry = (ms) => {
+    if (!closed) retryTimer = setTimeout(connect, ms);
+  };
+
+  const connect = async () => {
+    if (closed || !token) return;
+    let ticket;
+    try {
+      const res = await fetch(`${API_BASE}/events/ticket`, {
+        method: "POST",
+        headers: authHeaders(),
+      });
+      if (!res.ok) throw new Error("ticket");
+      ticket = (await res.json()).ticket;
+    } catch {
+      retry(5000);
+      return;
+    }
+    if (closed) return;
+
+    const url = new URL(`${API_BASE}/events`);
+    url.searchParams.set("ticket", ticket);
+    if (lastEventId) url.searchParams.set("lastEventId", lastEventId);
+
+    es = new EventSource(url);
+    for (const [type, fn] of Object.entries(handlers)) {
+      es.addEventListener(type, (e) => {
+        if (e.lastEventId) lastEventId = e.lastEventId;
+        let data = null;
+        try {
+          data = JSON.parse(e.data);
+        } catch {
+          // abaikan payload rusak
+        }
+        fn(data);
+      });
+    }
+    es.onerror = () => {
+      es.close();
+      retry(3000);
+    };
+  };
+
+  connect();
+  return () => {
+    closed = true;
+    clearTimeout(retryTimer);
+    if (es) es.close();
+  };
+}
+
 // ===================== AUTH =====================
+// access token pendek (expires_in detik) + refresh token yang dirotasi;
+// access token diperbarui otomatis 1 menit sebelum habis.
+// Semua tab memakai satu sesi: refresh hanya dijalankan satu tab sekaligus
+// (Web Locks) dan hasilnya dibagi lewat localStorage. Refresh token lama
+// yang dipakai lagi membuat server mencabut sesi, jadi tab lain tidak boleh
+// ikut merotasi token yang sama.
+const REFRESH_KEY = "cc-helper-refresh";
+const ACCESS_KEY = "cc-helper-access"; // { token, expires_at (ms), user }
+const REFRESH_LOCK = "cc-helper-refresh-lock";
+let refreshTimer = null;
+
+function scheduleRefresh(expiresAt) {
+  clearTimeout(refreshTimer);
+  const wait = Math.max(expiresAt - Date.now() - 60 * 1000, 10 * 1000);
+  refreshTimer = setTimeout(() => refreshSession().catch(() => {}), wait);
+}
+
+function readShared() {
+  try {
+    return JSON.parse(localStorage.getItem(ACCESS_KEY));
+  } catch {
+    return null;
+  }
+}
+
+function adoptShared(shared) {
+  setToken(shared.token);
+  scheduleRefresh(shared.expires_at);
+}
+
+function saveSession(data) {
+  const shared = {
+    token: data.token,
+    expires_at: Date.now() + (data.expires_in || 0) * 1000,
+    user: data.user,
+  };
+  localStorage.setItem(REFRESH_KEY, data.refresh_token);
+  localStorage.setItem(ACCESS_KEY, JSON.stringify(shared));
+  adoptShared(shared);
+}
+
+// tab lain baru refresh / login -> pakai token yang sama
+window.addEventListener("storage", (e) => {
+  if (e.key !== ACCESS_KEY || !e.newValue || !token) return;
+  const shared = readShared();
+  if (shared && shared.token) adoptShared(shared);
+});
+
+function withRefreshLock(fn) {
+  if (navigator.locks) return navigator.locks.request(REFRESH_LOCK, fn);
+  return fn();
+}
+
+export function clearSession() {
+  clearTimeout(refreshTimer);
+  setToken(null);
+  localStorage.removeItem("cc-helper-token"); // sisa versi lama
+  localStorage.removeItem(REFRESH_KEY);
+  localStorage.removeItem(ACCESS_KEY);
+}
+
 export async function login(username, password) {
   const res = await fetch(`${API_BASE}/auth/login`, {
     method: "POST",
@@ -35,12 +159,50 @@ export async function login(username, password) {
   if (!res.ok) throw new Error("Login failed");
   const data = await res.json();
 
-  setToken(data.token);
-  localStorage.setItem("cc-helper-token", data.token);
-
+  saveSession(data);
   return data;
 }
 
+// POST /auth/refresh -> { token, refresh_token, expires_in, user }
+// Kalau tab lain sudah refresh selagi menunggu lock, token-nya langsung dipakai.
+export function refreshSession() {
+  return withRefreshLock(async () => {
+    const shared = readShared();
+    if (shared && shared.token !== token && shared.expires_at - Date.now() > 60 * 1000) {
+      adoptShared(shared);
+      return { token: shared.token, user: shared.user };
+    }
+
+    const refreshToken = localStorage.getItem(REFRESH_KEY);
+    if (!refreshToken) throw new Error("Not logged in");
+
+    const res = await fetch(`${API_BASE}/auth/refresh`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    });
+    if (!res.ok) {
+      clearSession();
+      throw new Error("Session expired");
+    }
+    const data = await res.json();
+    saveSession(data);
+    return data;
+  });
+}
+
+// POST /auth/logout (cabut sesi di server), storage tetap dibersihkan
+export async function logout() {
+  try {
+    await fetch(`${API_BASE}/auth/logout`, {
+      method: "POST",
+      headers: authHeaders(),
+    });
+  } finally {
+    clearSession();
+  }
+}
+
 export async function fetchMe() {
   const res = await fetch(`${API_BASE}/auth/me`, { headers: authHeaders() });
   if (!res.ok) throw new Error("Not logged in");
@@ -59,6 +221,16 @@ export async function fetchCategories({ kind = "product", parentId } = {}) {
   return res.json(); // [{id, kind, name, parent_id}, ...]
 }
 
+// GET /categories/tree?kind=product|script -> semua level + counts + children
+export async function fetchCategoryTree(kind = "product") {
+  const url = new URL(`${API_BASE}/categories/tree`);
+  url.searchParams.set("kind", kind);
+
+  const res = await fetch(url, { headers: authHeaders() });
+  if (!res.ok) throw new Error("Failed to fetch category tree");
+  return res.json(); // [{id, name, sort_order, counts, children: [...]}, ...]
+}
+
 // GET /categories/path/:id  -> { path: "A / B / C" }
 export async function fetchCategoryPath(id) {
   const res = await fetch(`${API_BASE}/categories/path/${id}`, {
@@ -69,8 +241,8 @@ export async function fetchCategoryPath(id) {
 }
 
 // ADMIN: POST /admin/categories
-// body: { kind, name, parent_id }
-export async function createCategoryMaster({ kind, name, parentId }) {
+// body: { kind, name, parent_id, description?, icon? }
+export async function createCategoryMaster({ kind, name, parentId, description, icon }) {
   const res = await fetch(`${API_BASE}/admin/categories`, {
     method: "POST",
     headers: jsonHeaders(),
@@ -78,6 +250,8 @@ export async function createCategoryMaster({ kind, name, parentId }) {
       kind,
       name,
       parent_id: parentId ?? null,
+      description,
+      icon,
     }),
   });
 
@@ -88,9 +262,37 @@ export async function createCategoryMaster({ kind, name, parentId }) {
   return res.json(); // { id }
 }
 
-// ADMIN: DELETE /admin/categories/:id
-export async function deleteCategory(id) {
-  const res = await fetch(`${API_BASE}/admin/categories/${id}`, {
+// ADMIN: PUT /admin/categories/reorder
+// ids = semua sub-kategori parentId (null = root) dalam urutan baru
+export async function reorderCategories({ kind, parentId, ids }) {
+  const res = await fetch(`${API_BASE}/admin/categories/reorder`, {
+    method: "PUT",
+    headers: jsonHeaders(),
+    body: JSON.stringify({ kind, parent_id: parentId ?? null, ids }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Reorder categories failed");
+  }
+  return res.json();
+}
+
+// ADMIN: GET /admin/categories/:id/impact -> { category, descendants, products }
+export async function fetchCategoryImpact(id) {
+  const res = await fetch(`${API_BASE}/admin/categories/${id}/impact`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Failed to fetch category impact");
+  return res.json();
+}
+
+// ADMIN: DELETE /admin/categories/:id?strategy=block|move|archive&targetId=...
+export async function deleteCategory(id, { strategy = "block", targetId } = {}) {
+  const url = new URL(`${API_BASE}/admin/categories/${id}`);
+  url.searchParams.set("strategy", strategy);
+  if (targetId) url.searchParams.set("targetId", String(targetId));
+
+  const res = await fetch(url, {
     method: "DELETE",
     headers: authHeaders(),
   });
@@ -104,15 +306,30 @@ export async function deleteCategory(id) {
 // ===================== LIST & DETAIL =====================
 // GET /products?categoryId=...&q=...
 // GET /scripts?categoryId=...&q=...
+// list endpoint membalas { items, total, page, page_size, sort }
+const MAX_PAGE_SIZE = 200;
+
+// fetchAllPages mengambil semua halaman list endpoint (page_size maksimum)
+// sampai jumlah item mencapai total, lalu mengembalikan gabungan items.
+async function fetchAllPages(url, errorMessage) {
+  const items = [];
+  url.searchParams.set("page_size", String(MAX_PAGE_SIZE));
+  for (let page = 1; ; page++) {
+    url.searchParams.set("page", String(page));
+    const res = await fetch(url, { headers: authHeaders() });
+    if (!res.ok) throw new Error(errorMessage);
+    const data = await res.json();
+    items.push(...data.items);
+    if (data.items.length === 0 || items.length >= data.total) return items;
+  }
+}
+
 export async function fetchList(kind, { categoryId, q } = {}) {
   const path = kind === "product" ? "products" : "scripts";
   const url = new URL(`${API_BASE}/${path}`);
   if (categoryId) url.searchParams.set("categoryId", String(categoryId));
   if (q) url.searchParams.set("q", q);
-
-  const res = await fetch(url, { headers: authHeaders() });
-  if (!res.ok) throw new Error("Failed to fetch list");
-  return res.json();
+  return fetchAllPages(url, "Failed to fetch list");
 }
 
 // GET /products/slug/:slug
@@ -126,6 +343,15 @@ export async function fetchDetail(kind, slug) {
   return res.json();
 }
 
+// GET /content-changes/:id -> { title_before, title_after, summary, diff, product }
+export async function fetchContentChange(id) {
+  const res = await fetch(`${API_BASE}/content-changes/${id}`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Not found");
+  return res.json();
+}
+
 // GET /search?q=...
 export async function searchAll(q) {
   const url = new URL(`${API_BASE}/search`);
@@ -133,7 +359,17 @@ export async function searchAll(q) {
 
   const res = await fetch(url, { headers: authHeaders() });
   if (!res.ok) throw new Error("Search failed");
-  return res.json(); // { products: [...], scripts: [...] }
+  return res.json(); // { products: [...], scripts: [...], query_id }
+}
+
+// POST /search/click (analytics, gagal diabaikan)
+export function logSearchClick(queryId, type, id) {
+  if (!queryId) return;
+  fetch(`${API_BASE}/search/click`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json", ...authHeaders() },
+    body: JSON.stringify({ query_id: queryId, type, id }),
+  }).catch(() => {});
 }
 
 // ===================== UPLOAD =====================
@@ -180,8 +416,13 @@ export async function createContent(
 }
 
 // PUT /admin/products/:id  or  /admin/scripts/:id
-// body: { title, categoryId, blocks }
-export async function updateContent(kind, id, { title, categoryId, blocks }) {
+// body: { title, categoryId, blocks, notifyUpdate, noticeTitle, noticeSeverity }
+// notifyUpdate = umumkan perubahan di ticker (ringkasan blok otomatis)
+export async function updateContent(
+  kind,
+  id,
+  { title, categoryId, blocks, notifyUpdate, noticeTitle, noticeSeverity }
+) {
   const path = kind === "product" ? "products" : "scripts";
 
   const res = await fetch(`${API_BASE}/admin/${path}/${id}`, {
@@ -191,6 +432,9 @@ export async function updateContent(kind, id, { title, categoryId, blocks }) {
       title,
       categoryId,
       blocks,
+      notifyUpdate: !!notifyUpdate,
+      noticeTitle: noticeTitle || "",
+      noticeSeverity: noticeSeverity || "",
     }),
   });
 
@@ -198,7 +442,7 @@ export async function updateContent(kind, id, { title, categoryId, blocks }) {
     const text = await res.text();
     throw new Error(text || "Update failed");
   }
-  return res.json(); // { ok: true, slug }
+  return res.json(); // { ok: true, slug, notice_id?, notice_error? }
 }
 
 // DELETE /admin/products/:id  or  /admin/scripts/:id
@@ -220,19 +464,75 @@ export async function deleteContent(kind, id) {
 // ===================== BREAKING NEWS =====================
 // GET /breaking-news (agent: active)
 export async function fetchActiveBreakingNews() {
-  const res = await fetch(`${API_BASE}/breaking-news`, {
-    headers: authHeaders(),
-  });
-  if (!res.ok) throw new Error("Failed to fetch breaking news");
-  return res.json();
+  return fetchAllPages(
+    new URL(`${API_BASE}/breaking-news`),
+    "Failed to fetch breaking news"
+  );
 }
 
 // GET /admin/breaking-news (admin: all)
 export async function fetchAllBreakingNews() {
-  const res = await fetch(`${API_BASE}/admin/breaking-news`, {
-    headers: authHeaders(),
+  return fetchAllPages(
+    new URL(`${API_BASE}/admin/breaking-news`),
+    "Failed to fetch breaking news (admin)"
+  );
+}
+
+// POST /admin/announcements (tanpa product)
+// body: { title, isActive, severity, startsAt, endsAt, body: blocks, linkProductId, linkS2NodeId }
+export async function createAnnouncement({
+  title,
+  isActive = true,
+  severity,
+  startsAt,
+  endsAt,
+  body,
+  linkProductId,
+  linkS2NodeId,
+}) {
+  const res = await fetch(`${API_BASE}/admin/announcements`, {
+    method: "POST",
+    headers: jsonHeaders(),
+    body: JSON.stringify({
+      title,
+      isActive: !!isActive,
+      severity: severity || "info",
+      startsAt: startsAt || null,
+      endsAt: endsAt || null,
+      body: body || [],
+      linkProductId: linkProductId || null,
+      linkS2NodeId: linkS2NodeId || null,
+    }),
   });
-  if (!res.ok) throw new Error("Failed to fetch breaking news (admin)");
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Create announcement failed");
+  }
+  return res.json(); // { id }
+}
+
+// PUT /admin/breaking-news/:id (parsial: field lain seperti isMandatory,
+// body & link announcement tidak berubah)
+// body: { title, isActive, severity: info|warning|critical, startsAt, endsAt }
+export async function updateBreakingNews(
+  id,
+  { title, isActive, severity, startsAt, endsAt }
+) {
+  const res = await fetch(`${API_BASE}/admin/breaking-news/${id}`, {
+    method: "PUT",
+    headers: jsonHeaders(),
+    body: JSON.stringify({
+      title,
+      isActive: !!isActive,
+      severity: severity || "info",
+      startsAt: startsAt || null,
+      endsAt: endsAt || null,
+    }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Update breaking news failed");
+  }
   return res.json();
 }
 
@@ -249,6 +549,129 @@ export async function deleteBreakingNews(id) {
   return res.json();
 }
 
+// GET /breaking-news/unacknowledged (agent: item wajib yang belum dibaca)
+export async function fetchUnacknowledgedBreakingNews() {
+  return fetchAllPages(
+    new URL(`${API_BASE}/breaking-news/unacknowledged`),
+    "Failed to fetch unacknowledged items"
+  );
+}
+
+// POST /breaking-news/:id/ack
+export async function acknowledgeBreakingNews(id) {
+  const res = await fetch(`${API_BASE}/breaking-news/${id}/ack`, {
+    method: "POST",
+    headers: authHeaders(),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Acknowledge failed");
+  }
+  return res.json();
+}
+
+// GET /reports/breaking-news/acks (supervisor/admin) -> rekap per item wajib
+export async function fetchBreakingNewsAckSummary() {
+  const res = await fetch(`${API_BASE}/reports/breaking-news/acks`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Failed to fetch acknowledgement report");
+  return res.json();
+}
+
+// GET /reports/breaking-news/:id/acks -> { item, agents, acknowledged, users }
+export async function fetchBreakingNewsAckReport(id) {
+  const res = await fetch(`${API_BASE}/reports/breaking-news/${id}/acks`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Failed to fetch acknowledgement report");
+  return res.json();
+}
+
+// ===================== TEAMS & TARGETING (ADMIN) =====================
+// GET /admin/teams -> [{ id, name, member_ids }]
+export async function fetchTeams() {
+  const res = await fetch(`${API_BASE}/admin/teams`, { headers: authHeaders() });
+  if (!res.ok) throw new Error("Failed to fetch teams");
+  return res.json();
+}
+
+export async function createTeam(name) {
+  const res = await fetch(`${API_BASE}/admin/teams`, {
+    method: "POST",
+    headers: jsonHeaders(),
+    body: JSON.stringify({ name }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Create team failed");
+  }
+  return res.json(); // { id }
+}
+
+export async function updateTeam(id, name) {
+  const res = await fetch(`${API_BASE}/admin/teams/${id}`, {
+    method: "PUT",
+    headers: jsonHeaders(),
+    body: JSON.stringify({ name }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Update team failed");
+  }
+  return res.json();
+}
+
+export async function deleteTeam(id) {
+  const res = await fetch(`${API_BASE}/admin/teams/${id}`, {
+    method: "DELETE",
+    headers: authHeaders(),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Delete team failed");
+  }
+  return res.json();
+}
+
+// PUT /admin/teams/:id/members -> mengganti semua anggota
+export async function setTeamMembers(id, userIds) {
+  const res = await fetch(`${API_BASE}/admin/teams/${id}/members`, {
+    method: "PUT",
+    headers: jsonHeaders(),
+    body: JSON.stringify({ user_ids: userIds }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Update team members failed");
+  }
+  return res.json();
+}
+
+// type = "breaking-news" | "category" | "product"
+// GET /admin/targets/:type/:id -> { team_ids } ([] = semua user)
+export async function fetchTargets(type, id) {
+  const res = await fetch(`${API_BASE}/admin/targets/${type}/${id}`, {
+    headers: authHeaders(),
+  });
+  if (!res.ok) throw new Error("Failed to fetch targets");
+  const data = await res.json();
+  return data.team_ids;
+}
+
+export async function setTargets(type, id, teamIds) {
+  const res = await fetch(`${API_BASE}/admin/targets/${type}/${id}`, {
+    method: "PUT",
+    headers: jsonHeaders(),
+    body: JSON.stringify({ team_ids: teamIds }),
+  });
+  if (!res.ok) {
+    const text = await res.text();
+    throw new Error(text || "Update targets failed");
+  }
+  return res.json();
+}
+
 // ===================== S2PASS (AGENT + ADMIN) =====================
 // GET /s2pass/nodes?main=info|request|complaint&parentId=...
 export async function fetchS2Nodes({ main, parentId } = {}) {
@@ -257,10 +680,7 @@ export async function fetchS2Nodes({ main, parentId } = {}) {
   const url = new URL(`${API_BASE}/s2pass/nodes`);
   url.searchParams.set("main", main);
   if (parentId) url.searchParams.set("parentId", String(parentId));
-
-  const res = await fetch(url, { headers: authHeaders() });
-  if (!res.ok) throw new Error("Failed to fetch S2PASS nodes");
-  return res.json();
+  return fetchAllPages(url, "Failed to fetch S2PASS nodes");
 }
 
 // POST /admin/s2pass/nodes
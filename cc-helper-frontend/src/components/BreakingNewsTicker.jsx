@@ -32,6 +32,24 @@ export default function BreakingNewsTicker() {
   if (loopItems.length === 0) return null;
 
   function handleClick(bn) {
+    // pemberitahuan update product -> halaman diff
+    if (bn.change_id) {
+      navigate(`/changes/${bn.change_id}`);
+      return;
+    }
+
+    // announcement: link opsional ke product/script/S2 (atau tidak ada)
+    if (bn.kind === "announcement") {
+      const link = bn.link;
+      if (!link) return;
+      if (link.type === "s2") {
+        navigate(`/s2pass?main=${link.main_type}&node=${link.id}`);
+        return;
+      }
+      navigate(link.type === "script" ? `/script/${link.slug}` : `/product/${link.slug}`);
+      return;
+    }
+
     const slug = bn.product_slug || bn.productSlug || (bn.product && bn.product.slug);
     if (!slug) return;
 
@@ -55,6 +73,9 @@ export default function BreakingNewsTicker() {
               className="mx-2 px-3 py-0.5 rounded-full bg-red-600/80 border border-red-300 text-[11px] shadow-sm whitespace-nowrap hover:bg-red-500 hover:border-white transition cursor-pointer"
             >
               {bn.title}
+              {bn.change_summary && (
+                <span className="ml-1 opacity-80">({bn.change_summary.text})</span>
+              )}
             </button>
           ))}
         </div>
@@ -0,0 +1,105 @@
+// src/pages/ChangeDiffPage.jsx
+// Diff update product (dibuka dari item "Update: ..." di ticker)
+import { useEffect, useState } from "react";
+import { Link, useParams } from "react-router-dom";
+import { fetchContentChange } from "../api";
+import BlockRenderer from "../components/BlockRenderer";
+
+const OP_STYLE = {
+  added: { label: "Ditambah", className: "border-emerald-300 bg-emerald-50" },
+  removed: { label: "Dihapus", className: "border-red-300 bg-red-50" },
+  changed: { label: "Diubah", className: "border-amber-300 bg-amber-50" },
+};
+
+export default function ChangeDiffPage() {
+  const { id } = useParams();
+  const [change, setChange] = useState(null);
+  const [error, setError] = useState("");
+  const [showAll, setShowAll] = useState(false);
+
+  useEffect(() => {
+    fetchContentChange(id)
+      .then((data) => {
+        setChange(data);
+        setError("");
+      })
+      .catch(() => {
+        setError("Perubahan tidak ditemukan");
+        setChange(null);
+      });
+  }, [id]);
+
+  if (error) return <div className="text-red-500 text-sm">{error}</div>;
+  if (!change) return <div className="text-sm text-slate-500">Loading...</div>;
+
+  const product = change.product;
+  const rows = (change.diff || []).filter((d) => showAll || d.op !== "unchanged");
+
+  return (
+    <div className="max-w-4xl">
+      <h1 className="text-2xl font-semibold mb-1">{change.title_after}</h1>
+      {change.title_before !== change.title_after && (
+        <div className="text-xs text-slate-500 mb-1">
+          Judul sebelumnya: <span className="line-through">{change.title_before}</span>
+        </div>
+      )}
+      <div className="text-xs text-slate-500 mb-4">
+        {new Date(change.created_at).toLocaleString("id-ID")} • {change.summary?.text}
+        {product && (
+          <>
+            {" • "}
+            <Link
+              to={product.kind === "script" ? `/script/${product.slug}` : `/product/${product.slug}`}
+              className="text-sky-700 hover:underline"
+            >
+              Buka versi terbaru
+            </Link>
+          </>
+        )}
+      </div>
+
+      <label className="flex items-center gap-2 text-xs text-slate-600 mb-3">
+        <input type="checkbox" checked={showAll} onChange={(e) => setShowAll(e.target.checked)} />
+        Tampilkan blok yang tidak berubah
+      </label>
+
+      {rows.length === 0 && (
+        <div className="text-sm text-slate-500">Tidak ada perubahan isi.</div>
+      )}
+
+      <div className="space-y-3">
+        {rows.map((d, idx) => {
+          const style = OP_STYLE[d.op];
+          return (
+            <div
+              key={idx}
+              className={`rounded-xl border p-3 ${style ? style.className : "border-slate-200"}`}
+            >
+              {style && (
+                <div className="text-[11px] font-semibold uppercase tracking-wide text-slate-600 mb-2">
+                  {style.label}
+                </div>
+              )}
+              {d.op === "changed" ? (
+                <div className="grid md:grid-cols-2 gap-3">
+                  <div className="opacity-70">
+                    <div className="text-[11px] text-slate-500 mb-1">Sebelum</div>
+                    <BlockRenderer blocks={[d.old]} />
+                  </div>
+                  <div>
+                    <div className="text-[11px] text-slate-500 mb-1">Sesudah</div>
+                    <BlockRenderer blocks={[d.new]} />
+                  </div>
+                </div>
+              ) : (
+                <div className={d.op === "removed" ? "opacity-70" : ""}>
+                  <BlockRenderer blocks={[d.new || d.old]} />
+                </div>
+              )}
+            </div>
+          );
+        })}
+      </div>
+    </div>
+  );
+}
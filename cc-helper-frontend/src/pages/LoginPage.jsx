@@ -1,5 +1,5 @@
 import { useState } from "react";
-import { login, setToken } from "../api";
+import { login } from "../api";
 
 export default function LoginPage({ onLogin }) {
   const [username, setUsername] = useState("admin");
@@ -11,8 +11,6 @@ export default function LoginPage({ onLogin }) {
     setError("");
     try {
       const data = await login(username, password);
-      localStorage.setItem("cc-helper-token", data.token);
-      setToken(data.token);
       onLogin(data.user);
     } catch (err) {
       setError("Username / password salah atau server tidak merespon");
@@ -1,6 +1,6 @@
 import { useEffect, useState } from "react";
 import { useLocation, useNavigate } from "react-router-dom";
-import { searchAll } from "../api";
+import { logSearchClick, searchAll } from "../api";
 import { firstTextFromBlocks } from "../utils";
 
 export default function SearchPage() {
@@ -8,6 +8,7 @@ export default function SearchPage() {
   const navigate = useNavigate();
   const [products, setProducts] = useState([]);
   const [scripts, setScripts] = useState([]);
+  const [queryId, setQueryId] = useState(null);
   const [error, setError] = useState("");
 
   const params = new URLSearchParams(location.search);
@@ -19,6 +20,7 @@ export default function SearchPage() {
       .then((data) => {
         setProducts(data.products || []);
         setScripts(data.scripts || []);
+        setQueryId(data.query_id || null);
         setError("");
       })
       .catch(() => {
@@ -28,7 +30,9 @@ export default function SearchPage() {
       });
   }, [q]);
 
-  function open(kind, slug) {
+  function open(kind, item) {
+    logSearchClick(queryId, kind, item.id);
+    const slug = item.slug;
     navigate(kind === "product" ? `/product/${slug}` : `/script/${slug}`);
   }
 
@@ -53,7 +57,7 @@ export default function SearchPage() {
             {products.map((p) => (
               <button
                 key={p.id}
-                onClick={() => open("product", p.slug)}
+                onClick={() => open("product", p)}
                 className="w-full text-left px-3 py-2 border-b hover:bg-slate-50"
               >
                 <div className="text-xs text-slate-500">{p.category}</div>
@@ -78,7 +82,7 @@ export default function SearchPage() {
             {scripts.map((s) => (
               <button
                 key={s.id}
-                onClick={() => open("script", s.slug)}
+                onClick={() => open("script", s)}
                 className="w-full text-left px-3 py-2 border-b hover:bg-slate-50"
               >
                 <div className="text-xs text-slate-500">{s.category}</div>
@@ -29,21 +29,44 @@ func main() {
 	categoryRepo := repository.NewCategoryRepository(database)
 	breakingNewsRepo := repository.NewBreakingNewsRepository(database)
 	s2NodeRepo := repository.NewS2NodeRepository(database)
+	attributeRepo := repository.NewAttributeRepository(database)
+	rateRepo := repository.NewRateRepository(database)
+	searchRepo := repository.NewSearchRepository(database)
+	searchLogRepo := repository.NewSearchLogRepository(database)
+	teamRepo := repository.NewTeamRepository(database)
+	contentChangeRepo := repository.NewContentChangeRepository(database)
+	sessionRepo := repository.NewSessionRepository(database)
 
 	// ===== SERVICE =====
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	userService := service.NewUserService(userRepo)
-	productService := service.NewProductService(productRepo, categoryRepo, breakingNewsRepo)
-	categoryService := service.NewCategoryService(categoryRepo)
-	s2Service := service.NewS2Service(s2NodeRepo, productRepo)
+	events := service.NewEventHub()
+	contentPolicy := service.NewContentPolicy(cfg.BaseURL)
+	authService := service.NewAuthService(userRepo, sessionRepo, events, cfg.JWTSecret)
+	userService := service.NewUserService(userRepo, sessionRepo, events)
+	autocompleter := service.NewAutocompleter(productRepo, categoryRepo, teamRepo)
+	attributeService := service.NewAttributeService(attributeRepo, categoryRepo)
+	productService := service.NewProductService(productRepo, categoryRepo, breakingNewsRepo, contentChangeRepo, attributeService, contentPolicy, autocompleter, events)
+	categoryService := service.NewCategoryService(categoryRepo, autocompleter, events)
+	breakingNewsService := service.NewBreakingNewsService(breakingNewsRepo, productRepo, s2NodeRepo, contentPolicy, events)
+	searchService := service.NewSearchService(productRepo, categoryRepo, s2NodeRepo, searchRepo, searchLogRepo, autocompleter)
+	s2Service := service.NewS2Service(s2NodeRepo, productRepo, contentPolicy)
+	rateService := service.NewRateService(rateRepo, productRepo)
+	calculatorService := service.NewCalculatorService(productRepo, rateService)
+	teamService := service.NewTeamService(teamRepo, userRepo, breakingNewsRepo, categoryRepo, productRepo, autocompleter, events)
 
 	// ===== HANDLER =====
 	authHandler := handler.NewAuthHandler(authService)
 	userHandler := handler.NewUserHandler(userService)
-	productHandler := handler.NewProductHandler(productService)
+	productHandler := handler.NewProductHandler(productService, searchService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
+	breakingNewsHandler := handler.NewBreakingNewsHandler(breakingNewsService)
+	eventsHandler := handler.NewEventsHandler(events)
 	uploadHandler := handler.NewUploadHandler(cfg.UploadDir, cfg.BaseURL)
 	s2Handler := handler.NewS2Handler(s2Service)
+	attributeHandler := handler.NewAttributeHandler(attributeService)
+	calculatorHandler := handler.NewCalculatorHandler(calculatorService)
+	rateHandler := handler.NewRateHandler(rateService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	teamHandler := handler.NewTeamHandler(teamService)
 
 	r := gin.Default()
 
@@ -51,7 +74,7 @@ func main() {
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition"},
 		AllowCredentials: true,
 	}))
 
@@ -60,11 +83,18 @@ func main() {
 	api := r.Group("/api")
 	{
 		api.POST("/auth/login", authHandler.Login)
+		api.POST("/auth/refresh", authHandler.Refresh)
+
+		// push realtime (SSE); EventSource tidak bisa kirim header -> ?ticket=
+		// (tiket sekali pakai dari POST /events/ticket)
+		api.GET("/events", middleware.AuthStream(authService), eventsHandler.Stream)
 
 		auth := api.Group("/")
-		auth.Use(middleware.Auth(authService))
+		auth.Use(middleware.Auth(authService), middleware.Audience(teamService))
 		{
 			auth.GET("/auth/me", authHandler.Me)
+			auth.POST("/auth/logout", authHandler.Logout)
+			auth.POST("/events/ticket", authHandler.StreamTicket)
 
 			// ===== ADMIN =====
 			admin := auth.Group("/admin")
@@ -72,23 +102,70 @@ func main() {
 			{
 				admin.GET("/users", userHandler.List)
 				admin.POST("/users", userHandler.Create)
+				admin.POST("/users/:id/logout", userHandler.ForceLogout)
+
+				// Tim agent & target konten per tim
+				admin.GET("/teams", teamHandler.List)
+				admin.POST("/teams", teamHandler.Create)
+				admin.PUT("/teams/:id", teamHandler.Update)
+				admin.DELETE("/teams/:id", teamHandler.Delete)
+				admin.PUT("/teams/:id/members", teamHandler.SetMembers)
+				admin.GET("/targets/:type/:id", teamHandler.Targets)
+				admin.PUT("/targets/:type/:id", teamHandler.SetTargets)
 
 				// Categories tree
 				admin.POST("/categories", categoryHandler.Create)
+				admin.PUT("/categories/reorder", categoryHandler.Reorder)
+				admin.PUT("/categories/:id", categoryHandler.Update)
+				admin.PUT("/categories/:id/move", categoryHandler.Move)
+				admin.POST("/categories/:id/merge", categoryHandler.Merge)
+				admin.GET("/categories/:id/impact", categoryHandler.Impact)
 				admin.DELETE("/categories/:id", categoryHandler.Delete)
+				admin.POST("/categories/:id/restore", categoryHandler.Restore)
+
+				// Category attribute schema
+				admin.POST("/category-attributes", attributeHandler.Create)
+				admin.PUT("/category-attributes/:id", attributeHandler.Update)
+				admin.DELETE("/category-attributes/:id", attributeHandler.Delete)
 
 				// Products / Scripts
 				admin.POST("/products", productHandler.CreateProduct)
 				admin.PUT("/products/:id", productHandler.UpdateProduct)
 				admin.DELETE("/products/:id", productHandler.DeleteContent)
+				admin.POST("/products/:id/restore", productHandler.RestoreContent)
+
+				// Tabel rate effective-dated
+				admin.PUT("/products/:id/rates/:key", rateHandler.Save)
+				admin.DELETE("/products/:id/rates/:key", rateHandler.Delete)
 
 				admin.POST("/scripts", productHandler.CreateScript)
 				admin.PUT("/scripts/:id", productHandler.UpdateScript)
 				admin.DELETE("/scripts/:id", productHandler.DeleteContent)
+				admin.POST("/scripts/:id/restore", productHandler.RestoreContent)
 
 				// Breaking news admin
-				admin.GET("/breaking-news", productHandler.ListAllBreakingNews)
-				admin.DELETE("/breaking-news/:id", productHandler.DeleteBreakingNews)
+				admin.GET("/breaking-news", breakingNewsHandler.ListAll)
+				admin.POST("/announcements", breakingNewsHandler.CreateAnnouncement)
+				admin.PUT("/breaking-news/:id", breakingNewsHandler.Update)
+				admin.DELETE("/breaking-news/:id", breakingNewsHandler.Delete)
+
+				// Search config
+				admin.GET("/search/glossary", searchHandler.ListGlossary)
+				admin.POST("/search/glossary", searchHandler.CreateGlossaryTerm)
+				admin.DELETE("/search/glossary/:id", searchHandler.DeleteGlossaryTerm)
+				admin.GET("/search/synonyms", searchHandler.ListSynonyms)
+				admin.POST("/search/synonyms", searchHandler.CreateSynonym)
+				admin.GET("/search/synonyms/expand", searchHandler.ExpandQuery)
+				admin.PUT("/search/synonyms/:id", searchHandler.UpdateSynonym)
+				admin.DELETE("/search/synonyms/:id", searchHandler.DeleteSynonym)
+				admin.GET("/search/promotions", searchHandler.ListPromotions)
+				admin.POST("/search/promotions", searchHandler.CreatePromotion)
+				admin.PUT("/search/promotions/:id", searchHandler.UpdatePromotion)
+				admin.DELETE("/search/promotions/:id", searchHandler.DeletePromotion)
+
+				// Search analytics (format=csv untuk export)
+				admin.GET("/search/reports/trend", searchHandler.Trend)
+				admin.GET("/search/reports/:report", searchHandler.QueryReport)
 
 				// S2PASS ADMIN
 				admin.POST("/s2pass/nodes", s2Handler.CreateNode)
@@ -96,27 +173,51 @@ func main() {
 				admin.DELETE("/s2pass/nodes/:id", s2Handler.DeleteNode)
 			}
 
+			// ===== SUPERVISOR (laporan, admin juga boleh) =====
+			reports := auth.Group("/reports")
+			reports.Use(middleware.RequireRole("admin", "supervisor"))
+			{
+				reports.GET("/breaking-news/acks", breakingNewsHandler.AckSummary)
+				reports.GET("/breaking-news/:id/acks", breakingNewsHandler.AckReport)
+			}
+
 			// ===== AGENT =====
 			auth.GET("/products", productHandler.ListProducts)
 			auth.GET("/scripts", productHandler.ListScripts)
 
 			auth.GET("/products/slug/:slug", productHandler.GetProductBySlug)
 			auth.GET("/scripts/slug/:slug", productHandler.GetScriptBySlug)
+			auth.GET("/products/compare", productHandler.CompareProducts)
+			auth.GET("/content-changes/:id", productHandler.GetChange)
+
+			// Rate per tanggal berlaku
+			auth.GET("/products/:id/rates", rateHandler.List)
+			auth.GET("/products/:id/rates/:key", rateHandler.AsOf)
+			auth.GET("/products/:id/rates/:key/history", rateHandler.History)
 
 			// categories list by parent + path helper
 			auth.GET("/categories", categoryHandler.List)
+			auth.GET("/categories/tree", categoryHandler.Tree)
 			auth.GET("/categories/path/:id", categoryHandler.GetPath)
+			auth.GET("/categories/:id/attributes", attributeHandler.ListForCategory)
 
 			auth.GET("/search", productHandler.Search)
+			auth.GET("/search/autocomplete", productHandler.Autocomplete)
+			auth.POST("/search/click", productHandler.SearchClick)
 
 			// Breaking news
-			auth.GET("/breaking-news", productHandler.ListBreakingNews)
+			auth.GET("/breaking-news", breakingNewsHandler.ListActive)
+			auth.GET("/breaking-news/unacknowledged", breakingNewsHandler.ListUnacknowledged)
+			auth.POST("/breaking-news/:id/ack", breakingNewsHandler.Acknowledge)
 
 			// Upload
 			auth.POST("/upload", uploadHandler.Upload)
 
 			// S2PASS agent
 			auth.GET("/s2pass/nodes", s2Handler.ListNodes)
+
+			// Kalkulator cicilan
+			auth.POST("/calculator/loan", calculatorHandler.Loan)
 		}
 	}
 
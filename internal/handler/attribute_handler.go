@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttributeHandler struct {
+	svc *service.AttributeService
+}
+
+func NewAttributeHandler(s *service.AttributeService) *AttributeHandler {
+	return &AttributeHandler{svc: s}
+}
+
+type attributeRequest struct {
+	CategoryID   int64    `json:"category_id"`
+	Key          string   `json:"key"`
+	Label        string   `json:"label" binding:"required"`
+	Type         string   `json:"type" binding:"required"` // number/percentage/currency/enum/date
+	Unit         *string  `json:"unit"`
+	Options      []string `json:"options"`
+	IsFilterable *bool    `json:"is_filterable"`
+	SortOrder    int      `json:"sort_order"`
+}
+
+func (r *attributeRequest) toModel() *models.CategoryAttribute {
+	filterable := true
+	if r.IsFilterable != nil {
+		filterable = *r.IsFilterable
+	}
+	return &models.CategoryAttribute{
+		CategoryID:   r.CategoryID,
+		Key:          r.Key,
+		Label:        r.Label,
+		Type:         models.AttributeType(r.Type),
+		Unit:         r.Unit,
+		Options:      r.Options,
+		IsFilterable: filterable,
+		SortOrder:    r.SortOrder,
+	}
+}
+
+// GET /categories/:id/attributes -> schema efektif (termasuk warisan parent)
+func (h *AttributeHandler) ListForCategory(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	list, err := h.svc.SchemaFor(id, audience(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /admin/category-attributes
+func (h *AttributeHandler) Create(c *gin.Context) {
+	var body attributeRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.Create(body.toModel())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// PUT /admin/category-attributes/:id
+func (h *AttributeHandler) Update(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body attributeRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	a := body.toModel()
+	a.ID = id
+	if err := h.svc.Update(a); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// DELETE /admin/category-attributes/:id
+func (h *AttributeHandler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.Delete(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
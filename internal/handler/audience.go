@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audience = tim user login (diisi middleware.Audience).
+// Kalau tidak ada: hanya konten tanpa target.
+func audience(c *gin.Context) models.Audience {
+	if v, ok := c.Get("audience"); ok {
+		if aud, ok := v.(models.Audience); ok {
+			return aud
+		}
+	}
+	return models.Audience{}
+}
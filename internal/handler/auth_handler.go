@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/service"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +26,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
-	res, err := h.auth.Login(body.Username, body.Password)
+	client := service.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+	res, err := h.auth.Login(body.Username, body.Password, client)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -32,6 +35,52 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// POST /auth/refresh  {refresh_token} -> token baru + refresh token baru
+// (refresh token lama tidak berlaku lagi)
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	res, err := h.auth.Refresh(body.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// POST /auth/logout -> cabut sesi token yang dipakai
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.auth.Logout(c.GetInt64("user_id"), c.GetInt64("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// POST /events/ticket -> { ticket, expires_in } untuk GET /events?ticket=
+func (h *AuthHandler) StreamTicket(c *gin.Context) {
+	ticket, err := h.auth.IssueStreamTicket(service.Claims{
+		UserID:    c.GetInt64("user_id"),
+		Username:  c.GetString("username"),
+		Role:      models.Role(c.GetString("role")),
+		SessionID: c.GetInt64("session_id"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
 func (h *AuthHandler) Me(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"id":       c.GetInt64("user_id"),
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/service"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,13 +23,55 @@ func NewBreakingNewsHandler(s *service.BreakingNewsService) *BreakingNewsHandler
 type breakingNewsUpdateReq struct {
 	Title    string `json:"title" binding:"required"`
 	IsActive bool   `json:"isActive"`
+	Severity string `json:"severity"` // info (default) / warning / critical
+	// wajib di-acknowledge tiap agent
+	IsMandatory bool       `json:"isMandatory"`
+	StartsAt    *time.Time `json:"startsAt"` // null = langsung tayang
+	EndsAt      *time.Time `json:"endsAt"`   // null = sampai dinonaktifkan
+
+	// khusus announcement (diabaikan untuk breaking news product/script)
+	Body          []models.ContentBlock `json:"body"`
+	LinkProductID *int64                `json:"linkProductId"`
+	LinkS2NodeID  *int64                `json:"linkS2NodeId"`
+}
+
+// breakingNewsReqFrom = isi request dari item tersimpan (dasar PUT parsial).
+// Body sengaja kosong: decode array JSON ke slice lama menyisakan field blok
+// lama, jadi body baru diisi dari item tersimpan setelah decode.
+func breakingNewsReqFrom(b *models.BreakingNews) breakingNewsUpdateReq {
+	return breakingNewsUpdateReq{
+		Title:         b.Title,
+		IsActive:      b.IsActive,
+		Severity:      b.Severity,
+		IsMandatory:   b.IsMandatory,
+		StartsAt:      b.StartsAt,
+		EndsAt:        b.EndsAt,
+		LinkProductID: b.LinkProductID,
+		LinkS2NodeID:  b.LinkS2NodeID,
+	}
+}
+
+func (r *breakingNewsUpdateReq) toModel() *models.BreakingNews {
+	return &models.BreakingNews{
+		Title:         r.Title,
+		IsActive:      r.IsActive,
+		IsMandatory:   r.IsMandatory,
+		Severity:      r.Severity,
+		StartsAt:      r.StartsAt,
+		EndsAt:        r.EndsAt,
+		Body:          r.Body,
+		LinkProductID: r.LinkProductID,
+		LinkS2NodeID:  r.LinkS2NodeID,
+	}
 }
 
 // List active (untuk agent header running text)
+// paging: page, page_size, sort=created_at|title|severity, default -created_at;
+// severity tertinggi selalu di depan
 func (h *BreakingNewsHandler) ListActive(c *gin.Context) {
-	data, err := h.svc.ListActive()
+	data, err := h.svc.ListActive(audience(c), pageRequest(c, "-"+models.SortCreatedAt))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, data)
@@ -33,24 +79,149 @@ func (h *BreakingNewsHandler) ListActive(c *gin.Context) {
 
 // List all (admin panel)
 func (h *BreakingNewsHandler) ListAll(c *gin.Context) {
-	data, err := h.svc.ListAll()
+	data, err := h.svc.ListAll(pageRequest(c, "-"+models.SortCreatedAt))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, data)
 }
 
+// GET /breaking-news/unacknowledged  (item wajib yang belum dibaca user login)
+func (h *BreakingNewsHandler) ListUnacknowledged(c *gin.Context) {
+	data, err := h.svc.ListUnacknowledged(c.GetInt64("user_id"), audience(c), pageRequest(c, "-"+models.SortCreatedAt))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// POST /breaking-news/:id/ack
+func (h *BreakingNewsHandler) Acknowledge(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.Acknowledge(id, c.GetInt64("user_id"), audience(c)); err != nil {
+		if errors.Is(err, service.ErrBreakingNewsNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// GET /reports/breaking-news/acks[?format=csv]  rekap per item wajib
+func (h *BreakingNewsHandler) AckSummary(c *gin.Context) {
+	list, err := h.svc.AckSummary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	rows := make([][]string, 0, len(list))
+	for _, s := range list {
+		rows = append(rows, []string{
+			strconv.FormatInt(s.ID, 10),
+			string(s.Kind),
+			s.Title,
+			s.Severity,
+			strconv.FormatBool(s.IsActive),
+			s.CreatedAt.Format(time.RFC3339),
+			strconv.Itoa(s.Agents),
+			strconv.Itoa(s.Acknowledged),
+			strconv.Itoa(s.Agents - s.Acknowledged),
+		})
+	}
+	writeCSV(c, "breaking-news-acks.csv",
+		[]string{"id", "kind", "title", "severity", "is_active", "created_at", "agents", "acknowledged", "pending"}, rows)
+}
+
+// GET /reports/breaking-news/:id/acks[?format=csv]  status per agent
+func (h *BreakingNewsHandler) AckReport(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	rep, err := h.svc.AckReport(id)
+	if err != nil {
+		if errors.Is(err, service.ErrBreakingNewsNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, rep)
+		return
+	}
+	rows := make([][]string, 0, len(rep.Users))
+	for _, u := range rep.Users {
+		at := ""
+		if u.AcknowledgedAt != nil {
+			at = u.AcknowledgedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{strconv.FormatInt(u.UserID, 10), u.Username, u.Name, at})
+	}
+	writeCSV(c, fmt.Sprintf("breaking-news-%d-acks.csv", id),
+		[]string{"user_id", "username", "name", "acknowledged_at"}, rows)
+}
+
+// POST /admin/announcements  (body sama dengan update)
+func (h *BreakingNewsHandler) CreateAnnouncement(c *gin.Context) {
+	var body breakingNewsUpdateReq
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.CreateAnnouncement(body.toModel())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// PUT /admin/breaking-news/:id
+// parsial: JSON di-decode di atas item tersimpan, jadi field yang tidak
+// dikirim tetap (kirim null untuk mengosongkan jadwal / link, body: [])
 func (h *BreakingNewsHandler) Update(c *gin.Context) {
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"error": "update belum diaktifkan",
-	})
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	cur, err := h.svc.Get(id)
+	if errors.Is(err, service.ErrBreakingNewsNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	body := breakingNewsReqFrom(cur)
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Body == nil {
+		body.Body = cur.Body
+	}
+	b := body.toModel()
+	b.ID = id
+	if err := h.svc.Update(b); err != nil {
+		if errors.Is(err, service.ErrBreakingNewsNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
 func (h *BreakingNewsHandler) Delete(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err := h.svc.Delete(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalculatorHandler struct {
+	svc *service.CalculatorService
+}
+
+func NewCalculatorHandler(s *service.CalculatorService) *CalculatorHandler {
+	return &CalculatorHandler{svc: s}
+}
+
+// POST /calculator/loan
+// body: { product_slug, principal, tenor_months, method, annual_rate, include_schedule }
+func (h *CalculatorHandler) Loan(c *gin.Context) {
+	var body models.LoanRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	res, err := h.svc.CalculateLoan(body, audience(c))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
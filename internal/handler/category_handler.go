@@ -3,6 +3,7 @@ package handler
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/service"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -21,6 +22,9 @@ type createCategoryRequest struct {
 	Kind     string `json:"kind" binding:"required"` // product/script
 	Name     string `json:"name" binding:"required"`
 	ParentID *int64 `json:"parent_id"`
+
+	Description *string `json:"description"`
+	Icon        *string `json:"icon"`
 }
 
 // GET /categories?kind=product&parentId=...
@@ -39,7 +43,7 @@ func (h *CategoryHandler) List(c *gin.Context) {
 		}
 	}
 
-	list, err := h.svc.ListByParent(kind, parentID)
+	list, err := h.svc.ListByParent(kind, parentID, audience(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -47,10 +51,24 @@ func (h *CategoryHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, list)
 }
 
+// GET /categories/tree?kind=product  -> semua level sekaligus + counts
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	kind := models.ContentKindProduct
+	if c.Query("kind") == "script" {
+		kind = models.ContentKindScript
+	}
+	tree, err := h.svc.Tree(kind, audience(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
 // GET /categories/path/:id  -> "A / B / C"
 func (h *CategoryHandler) GetPath(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	path, err := h.svc.BuildPathString(id)
+	path, err := h.svc.PathFor(id, audience(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
@@ -71,20 +89,149 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		kind = models.ContentKindScript
 	}
 
-	id, err := h.svc.Create(kind, body.Name, body.ParentID)
+	meta := service.CategoryMeta{Description: body.Description, Icon: body.Icon}
+	id, err := h.svc.Create(kind, body.Name, body.ParentID, meta)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
-// DELETE /admin/categories/:id
-func (h *CategoryHandler) Delete(c *gin.Context) {
+type updateCategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+	// tidak dikirim = tetap, "" = dikosongkan
+	Description *string `json:"description"`
+	Icon        *string `json:"icon"`
+}
+
+type reorderCategoriesRequest struct {
+	Kind     string  `json:"kind" binding:"required"`
+	ParentID *int64  `json:"parent_id"` // null = level root
+	IDs      []int64 `json:"ids" binding:"required"`
+}
+
+type moveCategoryRequest struct {
+	ParentID *int64 `json:"parent_id"` // null = jadi root
+}
+
+type mergeCategoryRequest struct {
+	TargetID int64 `json:"target_id" binding:"required"`
+}
+
+func (h *CategoryHandler) respondCategoryError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrCategoryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	respondError(c, http.StatusBadRequest, err)
+}
+
+// PUT /admin/categories/:id  {name, description?, icon?}
+func (h *CategoryHandler) Update(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err := h.svc.Delete(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var body updateCategoryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	meta := service.CategoryMeta{Description: body.Description, Icon: body.Icon}
+	if err := h.svc.Update(id, body.Name, meta); err != nil {
+		h.respondCategoryError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
+
+// PUT /admin/categories/reorder  {kind, parent_id, ids}
+func (h *CategoryHandler) Reorder(c *gin.Context) {
+	var body reorderCategoriesRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	kind := models.ContentKindProduct
+	if body.Kind == "script" {
+		kind = models.ContentKindScript
+	}
+	if err := h.svc.Reorder(kind, body.ParentID, body.IDs); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// PUT /admin/categories/:id/move  {parent_id}
+func (h *CategoryHandler) Move(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body moveCategoryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := h.svc.Move(id, body.ParentID); err != nil {
+		h.respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// POST /admin/categories/:id/merge  {target_id}
+func (h *CategoryHandler) Merge(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body mergeCategoryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	res, err := h.svc.Merge(id, body.TargetID)
+	if err != nil {
+		h.respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /admin/categories/:id/impact  -> turunan & product yang kena kalau dihapus
+func (h *CategoryHandler) Impact(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	impact, err := h.svc.Impact(id)
+	if err != nil {
+		h.respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, impact)
+}
+
+// POST /admin/categories/:id/restore  (kategori arsip + turunan & product-nya)
+func (h *CategoryHandler) Restore(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	res, err := h.svc.Restore(id)
+	if err != nil {
+		h.respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// DELETE /admin/categories/:id?strategy=block|move|archive&targetId=...
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+	var targetID *int64
+	if t := c.Query("targetId"); t != "" {
+		tid, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid targetId"})
+			return
+		}
+		targetID = &tid
+	}
+
+	res, err := h.svc.Delete(id, c.Query("strategy"), targetID)
+	if err != nil {
+		h.respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantsCSV: ?format=csv
+func wantsCSV(c *gin.Context) bool {
+	return c.Query("format") == "csv"
+}
+
+// writeCSV mengirim tabel sebagai file CSV (download)
+func writeCSV(c *gin.Context, filename string, header []string, rows [][]string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	for _, row := range rows {
+		safe := make([]string, len(row))
+		for i, cell := range row {
+			safe[i] = csvCell(cell)
+		}
+		_ = w.Write(safe)
+	}
+	w.Flush()
+}
+
+// csvCell: sel yang diawali karakter formula (=, +, -, @, tab, CR) diberi
+// awalan ' supaya tidak dieksekusi Excel / Sheets (mis. nama user "=HYPERLINK(...)")
+func csvCell(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError mengirim error service ke client. ValidationError dikirim
+// lengkap dengan daftar error per field, selain itu pakai status default.
+func respondError(c *gin.Context, status int, err error) {
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "fields": verr.Fields})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
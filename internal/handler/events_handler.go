@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const eventsHeartbeat = 25 * time.Second
+
+type EventsHandler struct {
+	hub *service.EventHub
+}
+
+func NewEventsHandler(hub *service.EventHub) *EventsHandler {
+	return &EventsHandler{hub: hub}
+}
+
+// GET /events  (SSE; token lewat header Authorization atau ?ticket=)
+// Reconnect: browser otomatis mengirim Last-Event-ID, atau ?lastEventId=.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	last := c.GetHeader("Last-Event-ID")
+	if last == "" {
+		last = c.Query("lastEventId")
+	}
+	lastID, _ := strconv.ParseInt(last, 10, 64)
+
+	sub := h.hub.Subscribe(userID, c.GetInt64("session_id"), lastID)
+	defer h.hub.Unsubscribe(sub)
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // jangan di-buffer reverse proxy
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	if sub.Resync {
+		writeEvent(w, models.Event{Type: models.EventResync, Data: gin.H{}})
+	}
+	for _, ev := range sub.Replay {
+		if !writeEvent(w, ev) {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				// terlalu lambat: putus, client reconnect & replay
+				return
+			}
+			if !writeEvent(w, ev) {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		w.Flush()
+	}
+}
+
+// writeEvent menulis satu event SSE; false = event logout (stream selesai)
+func writeEvent(w gin.ResponseWriter, ev models.Event) bool {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return true
+	}
+	if ev.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", ev.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	if ev.Type == models.EventLogout {
+		w.Flush()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageRequest: ?page=1&page_size=50&sort=-updated_at
+func pageRequest(c *gin.Context, defaultSort string) models.PageRequest {
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("page_size"))
+	return models.ParsePageRequest(page, size, c.Query("sort"), defaultSort)
+}
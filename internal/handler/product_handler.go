@@ -3,67 +3,154 @@ package handler
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/service"
+	"database/sql"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ProductHandler struct {
 	products *service.ProductService
+	search   *service.SearchService
 }
 
-func NewProductHandler(s *service.ProductService) *ProductHandler {
-	return &ProductHandler{products: s}
+func NewProductHandler(s *service.ProductService, search *service.SearchService) *ProductHandler {
+	return &ProductHandler{products: s, search: search}
 }
 
 type contentRequest struct {
 	Title         string                `json:"title" binding:"required"`
 	CategoryID    int64                 `json:"categoryId" binding:"required"`
 	Blocks        []models.ContentBlock `json:"blocks" binding:"required"`
+	Attributes    map[string]any        `json:"attributes"`
 	IsBreaking    bool                  `json:"isBreaking"`
 	BreakingTitle string                `json:"breakingTitle"`
+
+	// khusus update: umumkan perubahan di ticker (ringkasan otomatis)
+	NotifyUpdate   bool   `json:"notifyUpdate"`
+	NoticeTitle    string `json:"noticeTitle"`
+	NoticeSeverity string `json:"noticeSeverity"`
+}
+
+// notice = UpdateNotice kalau notifyUpdate dicentang
+func (r contentRequest) notice(c *gin.Context) *service.UpdateNotice {
+	if !r.NotifyUpdate {
+		return nil
+	}
+	return &service.UpdateNotice{Title: r.NoticeTitle, Severity: r.NoticeSeverity, UserID: c.GetInt64("user_id")}
 }
 
 // LIST
+// categoryId ikut semua sub-kategori; exactCategory=1 -> kategori itu saja
 func (h *ProductHandler) ListProducts(c *gin.Context) {
-	q := c.Query("q")
+	h.list(c, models.ContentKindProduct)
+}
+
+func (h *ProductHandler) ListScripts(c *gin.Context) {
+	h.list(c, models.ContentKindScript)
+}
+
+// productPage = envelope list + facet (kalau facets=1)
+type productPage struct {
+	*models.Page[*models.Product]
+	Facets  []*models.Facet `json:"facets,omitempty"`
+	QueryID int64           `json:"query_id,omitempty"` // ada kalau pakai q di halaman 1 (untuk POST /search/click)
+}
 
-	var catID *int64
+// list dipakai ListProducts & ListScripts.
+// Paging: page, page_size, sort=relevance|title|updated_at|created_at|popularity (prefix - = DESC)
+// Filter atribut: attr.<key>=a,b / attr.<key>.min= / attr.<key>.max=
+// facets=1 -> facet dihitung dari semua hasil filter
+func (h *ProductHandler) list(c *gin.Context, kind models.ContentKind) {
+	f := models.ProductFilter{
+		Kind:          kind,
+		Q:             c.Query("q"),
+		ExactCategory: c.Query("exactCategory") == "1" || c.Query("exactCategory") == "true",
+		Attributes:    parseAttributeFilters(c),
+		Audience:      audience(c),
+	}
 	if c.Query("categoryId") != "" {
 		id, _ := strconv.ParseInt(c.Query("categoryId"), 10, 64)
-		catID = &id
+		f.CategoryID = &id
+	}
+	defaultSort := models.SortTitle
+	if f.Q != "" {
+		defaultSort = models.SortRelevance
 	}
 
-	data, err := h.products.List(models.ContentKindProduct, q, catID)
+	page := pageRequest(c, defaultSort)
+	data, err := h.products.List(f, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	res := productPage{Page: data}
+	// query dicatat sekali saja di halaman pertama; paging berikutnya
+	// bukan pencarian baru (client pakai query_id dari halaman 1)
+	if f.Q != "" && page.Page == 1 {
+		source := models.SearchSourceProducts
+		if kind == models.ContentKindScript {
+			source = models.SearchSourceScripts
+		}
+		res.QueryID = h.search.LogQuery(c.GetInt64("user_id"), f.Q, source, data.Total, false)
+	}
+	if c.Query("facets") == "1" || c.Query("facets") == "true" {
+		res.Facets, err = h.products.Facets(f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, res)
 }
 
-func (h *ProductHandler) ListScripts(c *gin.Context) {
-	q := c.Query("q")
+func parseAttributeFilters(c *gin.Context) []models.AttributeFilter {
+	byKey := map[string]*models.AttributeFilter{}
+	var order []string
+	get := func(key string) *models.AttributeFilter {
+		f, ok := byKey[key]
+		if !ok {
+			f = &models.AttributeFilter{Key: key}
+			byKey[key] = f
+			order = append(order, key)
+		}
+		return f
+	}
 
-	var catID *int64
-	if c.Query("categoryId") != "" {
-		id, _ := strconv.ParseInt(c.Query("categoryId"), 10, 64)
-		catID = &id
+	for param, vals := range c.Request.URL.Query() {
+		if !strings.HasPrefix(param, "attr.") || len(vals) == 0 || vals[0] == "" {
+			continue
+		}
+		key := strings.TrimPrefix(param, "attr.")
+		v := vals[0]
+		switch {
+		case strings.HasSuffix(key, ".min"):
+			get(strings.TrimSuffix(key, ".min")).Min = &v
+		case strings.HasSuffix(key, ".max"):
+			get(strings.TrimSuffix(key, ".max")).Max = &v
+		default:
+			f := get(key)
+			for _, part := range strings.Split(v, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					f.Values = append(f.Values, part)
+				}
+			}
+		}
 	}
 
-	data, err := h.products.List(models.ContentKindScript, q, catID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	out := make([]models.AttributeFilter, 0, len(order))
+	for _, key := range order {
+		out = append(out, *byKey[key])
 	}
-	c.JSON(http.StatusOK, data)
+	return out
 }
 
 // DETAIL
 func (h *ProductHandler) GetProductBySlug(c *gin.Context) {
 	slug := c.Param("slug")
-	p, err := h.products.GetBySlug(models.ContentKindProduct, slug)
+	p, err := h.products.GetBySlug(models.ContentKindProduct, slug, audience(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
@@ -73,7 +160,7 @@ func (h *ProductHandler) GetProductBySlug(c *gin.Context) {
 
 func (h *ProductHandler) GetScriptBySlug(c *gin.Context) {
 	slug := c.Param("slug")
-	p, err := h.products.GetBySlug(models.ContentKindScript, slug)
+	p, err := h.products.GetBySlug(models.ContentKindScript, slug, audience(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
@@ -81,6 +168,22 @@ func (h *ProductHandler) GetScriptBySlug(c *gin.Context) {
 	c.JSON(http.StatusOK, p)
 }
 
+// COMPARE: GET /products/compare?slugs=a,b,c
+func (h *ProductHandler) CompareProducts(c *gin.Context) {
+	var slugs []string
+	for _, s := range strings.Split(c.Query("slugs"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			slugs = append(slugs, s)
+		}
+	}
+	res, err := h.products.Compare(models.ContentKindProduct, slugs, audience(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 // CREATE
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var body contentRequest
@@ -93,11 +196,12 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		body.Title,
 		body.CategoryID,
 		body.Blocks,
+		body.Attributes,
 		body.IsBreaking,
 		body.BreakingTitle,
 	)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id, "slug": slug})
@@ -114,17 +218,30 @@ func (h *ProductHandler) CreateScript(c *gin.Context) {
 		body.Title,
 		body.CategoryID,
 		body.Blocks,
+		body.Attributes,
 		body.IsBreaking,
 		body.BreakingTitle,
 	)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id, "slug": slug})
 }
 
 // UPDATE
+// updateResponse: notice_id / notice_error hanya ada kalau notifyUpdate diminta
+func updateResponse(res *service.UpdateResult) gin.H {
+	out := gin.H{"ok": true, "slug": res.Slug}
+	if res.NoticeID != 0 {
+		out["notice_id"] = res.NoticeID
+	}
+	if res.NoticeError != "" {
+		out["notice_error"] = res.NoticeError
+	}
+	return out
+}
+
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
 	var body contentRequest
@@ -132,12 +249,16 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
-	slug, err := h.products.Update(id, models.ContentKindProduct, body.Title, body.CategoryID, body.Blocks)
+	res, err := h.products.Update(id, models.ContentKindProduct, body.Title, body.CategoryID, body.Blocks, body.Attributes, body.notice(c))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "slug": slug})
+	c.JSON(http.StatusOK, updateResponse(res))
 }
 
 func (h *ProductHandler) UpdateScript(c *gin.Context) {
@@ -147,64 +268,119 @@ func (h *ProductHandler) UpdateScript(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
-	slug, err := h.products.Update(id, models.ContentKindScript, body.Title, body.CategoryID, body.Blocks)
+	res, err := h.products.Update(id, models.ContentKindScript, body.Title, body.CategoryID, body.Blocks, body.Attributes, body.notice(c))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, updateResponse(res))
+}
+
+// GET /content-changes/:id  -> snapshot update + diff per blok (link dari ticker)
+func (h *ProductHandler) GetChange(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	change, err := h.products.GetChange(id, audience(c))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "slug": slug})
+	c.JSON(http.StatusOK, change)
 }
 
 // DELETE
 func (h *ProductHandler) DeleteContent(c *gin.Context) {
 	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err := h.products.Delete(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// SEARCH: pakai list (q) untuk dua kind
+// POST /admin/products/:id/restore & /admin/scripts/:id/restore
+func (h *ProductHandler) RestoreContent(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.products.Restore(id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// SEARCH: unified search products, scripts, kategori & node S2PASS
+// GET /search?q=...&types=product,script,category,s2&categoryId=12
 func (h *ProductHandler) Search(c *gin.Context) {
 	q := c.Query("q")
 	if q == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
 		return
 	}
-	products, err1 := h.products.List(models.ContentKindProduct, q, nil)
-	scripts, err2 := h.products.List(models.ContentKindScript, q, nil)
-	if err1 != nil || err2 != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
-		return
+
+	opt := models.SearchOptions{Audience: audience(c), UserID: c.GetInt64("user_id")}
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			opt.Types = append(opt.Types, t)
+		}
+	}
+	if c.Query("categoryId") != "" {
+		id, _ := strconv.ParseInt(c.Query("categoryId"), 10, 64)
+		opt.CategoryID = &id
 	}
-	c.JSON(http.StatusOK, gin.H{"products": products, "scripts": scripts})
-}
 
-// Breaking news
-func (h *ProductHandler) ListBreakingNews(c *gin.Context) {
-	data, err := h.products.ListActiveBreakingNews()
+	res, err := h.search.Search(q, opt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed list breaking news"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	res.QueryID = h.search.LogQuery(c.GetInt64("user_id"), q, models.SearchSourceSearch, service.ResultCount(res), res.Fuzzy)
+	c.JSON(http.StatusOK, res)
+}
+
+type searchClickRequest struct {
+	QueryID int64  `json:"query_id" binding:"required"`
+	Type    string `json:"type" binding:"required"` // product/script/category/s2
+	ID      int64  `json:"id" binding:"required"`
 }
 
-func (h *ProductHandler) ListAllBreakingNews(c *gin.Context) {
-	data, err := h.products.ListAllBreakingNews()
+// POST /search/click -> catat hasil yang dibuka agent dari suatu query
+func (h *ProductHandler) SearchClick(c *gin.Context) {
+	var body searchClickRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.search.RecordClick(body.QueryID, c.GetInt64("user_id"), body.Type, body.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed list breaking news"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
-func (h *ProductHandler) DeleteBreakingNews(c *gin.Context) {
-	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err := h.products.DeleteBreakingNews(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed delete breaking news"})
+// AUTOCOMPLETE: saran typeahead per ketikan (judul, kategori, query terakhir)
+// GET /search/autocomplete?q=kp&limit=5
+func (h *ProductHandler) Autocomplete(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	res, err := h.search.Autocomplete(c.Query("q"), limit, c.GetInt64("user_id"), audience(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "autocomplete failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/service"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateHandler struct {
+	svc *service.RateService
+}
+
+func NewRateHandler(s *service.RateService) *RateHandler {
+	return &RateHandler{svc: s}
+}
+
+type rateTableRequest struct {
+	Label  string             `json:"label" binding:"required"`
+	Unit   *string            `json:"unit"`
+	Values []models.RateValue `json:"values"`
+}
+
+// GET /products/:id/rates -> semua tabel + nilai yang berlaku hari ini
+func (h *RateHandler) List(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	list, err := h.svc.List(id, audience(c))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GET /products/:id/rates/:key?date=2025-01-31
+func (h *RateHandler) AsOf(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	v, err := h.svc.AsOf(id, c.Param("key"), c.Query("date"), audience(c))
+	if err == service.ErrRateNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// GET /products/:id/rates/:key/history
+func (h *RateHandler) History(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	t, err := h.svc.History(id, c.Param("key"), audience(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PUT /admin/products/:id/rates/:key -> ganti seluruh baris tabel
+func (h *RateHandler) Save(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body rateTableRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	t := &models.RateTable{
+		ProductID: id,
+		Key:       c.Param("key"),
+		Label:     body.Label,
+		Unit:      body.Unit,
+	}
+	tableID, err := h.svc.Save(t, body.Values)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "id": tableID})
+}
+
+// DELETE /admin/products/:id/rates/:key
+func (h *RateHandler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.Delete(id, c.Param("key")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
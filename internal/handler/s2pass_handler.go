@@ -18,6 +18,7 @@ func NewS2Handler(s *service.S2Service) *S2Handler {
 }
 
 // === Agent: GET /s2pass/nodes?main=call|info|request|complaint&parentId=123 ===
+// paging: page, page_size, sort=sort_order|label|updated_at
 func (h *S2Handler) ListNodes(c *gin.Context) {
 	main := c.Query("main")
 	if main == "" {
@@ -32,9 +33,9 @@ func (h *S2Handler) ListNodes(c *gin.Context) {
 		}
 	}
 
-	list, err := h.svc.ListByParent(main, parentID)
+	list, err := h.svc.ListByParent(main, parentID, audience(c), pageRequest(c, models.SortOrder))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -48,7 +49,7 @@ type s2NodeRequest struct {
 	NodeType string `json:"node_type" binding:"required"` // menu/step
 	Label    string `json:"label" binding:"required"`
 
-	StepKind *string `json:"step_kind"` // script/input/link
+	StepKind *string `json:"step_kind"` // script/input/link/calculator
 
 	Title *string `json:"title"`
 	Body  *string `json:"body"`
@@ -64,6 +65,8 @@ type s2NodeRequest struct {
 	LinkSlug *string `json:"link_slug"`
 
 	SortOrder *int `json:"sort_order"`
+
+	CalcPreset *models.LoanCalcPreset `json:"calc_preset"` // khusus step_kind=calculator
 }
 
 func (r *s2NodeRequest) toModel(idOptional ...int64) *models.S2Node {
@@ -122,6 +125,7 @@ func (r *s2NodeRequest) toModel(idOptional ...int64) *models.S2Node {
 		LinkKind:         lk,
 		LinkSlug:         r.LinkSlug,
 		SortOrder:        sort,
+		CalcPreset:       r.CalcPreset,
 	}
 
 	if len(idOptional) > 0 {
@@ -140,7 +144,7 @@ func (h *S2Handler) CreateNode(c *gin.Context) {
 	n := body.toModel()
 	id, err := h.svc.CreateNode(n)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id})
@@ -156,7 +160,7 @@ func (h *S2Handler) UpdateNode(c *gin.Context) {
 	}
 	n := body.toModel(id)
 	if err := h.svc.UpdateNode(n); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/service"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler = endpoint admin untuk mengatur search (glossary, dst)
+// dan laporan analytics query.
+// Search untuk agent tetap di ProductHandler.Search.
+type SearchHandler struct {
+	svc *service.SearchService
+}
+
+func NewSearchHandler(s *service.SearchService) *SearchHandler {
+	return &SearchHandler{svc: s}
+}
+
+type glossaryRequest struct {
+	Term        string  `json:"term" binding:"required"`
+	Description *string `json:"description"`
+}
+
+// GET /admin/search/glossary
+func (h *SearchHandler) ListGlossary(c *gin.Context) {
+	list, err := h.svc.ListGlossary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /admin/search/glossary
+func (h *SearchHandler) CreateGlossaryTerm(c *gin.Context) {
+	var body glossaryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.CreateGlossaryTerm(body.Term, body.Description)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// DELETE /admin/search/glossary/:id
+func (h *SearchHandler) DeleteGlossaryTerm(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.DeleteGlossaryTerm(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+type synonymRequest struct {
+	Term          string   `json:"term" binding:"required"`
+	Synonyms      []string `json:"synonyms" binding:"required"`
+	Bidirectional *bool    `json:"bidirectional"` // default true
+}
+
+func (r *synonymRequest) toModel() *models.Synonym {
+	bidi := true
+	if r.Bidirectional != nil {
+		bidi = *r.Bidirectional
+	}
+	return &models.Synonym{Term: r.Term, Synonyms: r.Synonyms, Bidirectional: bidi}
+}
+
+// GET /admin/search/synonyms
+func (h *SearchHandler) ListSynonyms(c *gin.Context) {
+	list, err := h.svc.ListSynonyms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /admin/search/synonyms
+func (h *SearchHandler) CreateSynonym(c *gin.Context) {
+	var body synonymRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.CreateSynonym(body.toModel())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// PUT /admin/search/synonyms/:id
+func (h *SearchHandler) UpdateSynonym(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body synonymRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	syn := body.toModel()
+	syn.ID = id
+	if err := h.svc.UpdateSynonym(syn); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// DELETE /admin/search/synonyms/:id
+func (h *SearchHandler) DeleteSynonym(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.DeleteSynonym(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// GET /admin/search/synonyms/expand?q=bunga kpr -> lihat hasil ekspansi query
+func (h *SearchHandler) ExpandQuery(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	exp, err := h.svc.Expand(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exp)
+}
+
+// ===== Analytics =====
+
+func reportRange(c *gin.Context) (models.SearchReportRange, error) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	return service.ReportRange(c.Query("from"), c.Query("to"), limit)
+}
+
+// GET /admin/search/reports/:report?from=2024-01-01&to=2024-01-31&limit=100&format=csv
+// report: top | zero-results | no-click
+func (h *SearchHandler) QueryReport(c *gin.Context) {
+	rg, err := reportRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report := c.Param("report")
+	list, err := h.svc.QueryReport(report, rg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	rows := make([][]string, 0, len(list))
+	for _, s := range list {
+		rows = append(rows, []string{
+			s.Query,
+			strconv.Itoa(s.Searches),
+			strconv.Itoa(s.Users),
+			strconv.Itoa(s.ZeroResults),
+			strconv.Itoa(s.Clicks),
+			strconv.FormatFloat(s.AvgResults, 'f', 1, 64),
+			s.LastSearched.Format(time.RFC3339),
+		})
+	}
+	writeCSV(c, fmt.Sprintf("search-%s_%s_%s.csv", report, rg.From.Format(time.DateOnly), rg.To.Format(time.DateOnly)),
+		[]string{"query", "searches", "users", "zero_results", "clicks", "avg_results", "last_searched"}, rows)
+}
+
+// GET /admin/search/reports/trend?interval=day|week&from=&to=&format=csv
+func (h *SearchHandler) Trend(c *gin.Context) {
+	rg, err := reportRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := h.svc.Trend(rg, c.Query("interval"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !wantsCSV(c) {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	rows := make([][]string, 0, len(list))
+	for _, p := range list {
+		rows = append(rows, []string{
+			p.Period,
+			strconv.Itoa(p.Searches),
+			strconv.Itoa(p.Users),
+			strconv.Itoa(p.ZeroResults),
+			strconv.Itoa(p.Clicks),
+		})
+	}
+	writeCSV(c, fmt.Sprintf("search-trend_%s_%s.csv", rg.From.Format(time.DateOnly), rg.To.Format(time.DateOnly)),
+		[]string{"period", "searches", "users", "zero_results", "clicks"}, rows)
+}
+
+// ===== Promoted results (best bets) =====
+
+type promotionRequest struct {
+	Pattern   string     `json:"pattern" binding:"required"`
+	MatchType string     `json:"match_type"` // exact (default) / prefix / contains
+	ProductID int64      `json:"product_id" binding:"required"`
+	Position  int        `json:"position"`
+	Note      *string    `json:"note"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+}
+
+func (r *promotionRequest) toModel() *models.SearchPromotion {
+	return &models.SearchPromotion{
+		Pattern:   r.Pattern,
+		MatchType: models.PromotionMatch(r.MatchType),
+		ProductID: r.ProductID,
+		Position:  r.Position,
+		Note:      r.Note,
+		StartsAt:  r.StartsAt,
+		EndsAt:    r.EndsAt,
+	}
+}
+
+// GET /admin/search/promotions
+func (h *SearchHandler) ListPromotions(c *gin.Context) {
+	list, err := h.svc.ListPromotions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /admin/search/promotions
+func (h *SearchHandler) CreatePromotion(c *gin.Context) {
+	var body promotionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.CreatePromotion(body.toModel())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// PUT /admin/search/promotions/:id
+func (h *SearchHandler) UpdatePromotion(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body promotionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	p := body.toModel()
+	p.ID = id
+	if err := h.svc.UpdatePromotion(p); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// DELETE /admin/search/promotions/:id
+func (h *SearchHandler) DeletePromotion(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.DeletePromotion(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"cc-helper-backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	svc *service.TeamService
+}
+
+func NewTeamHandler(s *service.TeamService) *TeamHandler {
+	return &TeamHandler{svc: s}
+}
+
+func (h *TeamHandler) respondTeamError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrTeamNotFound) || errors.Is(err, service.ErrTargetNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	respondError(c, http.StatusInternalServerError, err)
+}
+
+// GET /admin/teams (beserta member_ids)
+func (h *TeamHandler) List(c *gin.Context) {
+	list, err := h.svc.List()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+type teamRequest struct {
+	Name string `json:"name"`
+}
+
+// POST /admin/teams  {name}
+func (h *TeamHandler) Create(c *gin.Context) {
+	var body teamRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	id, err := h.svc.Create(body.Name)
+	if err != nil {
+		h.respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// PUT /admin/teams/:id  {name}
+func (h *TeamHandler) Update(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body teamRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := h.svc.Rename(id, body.Name); err != nil {
+		h.respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *TeamHandler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err := h.svc.Delete(id); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// PUT /admin/teams/:id/members  {user_ids} (mengganti semua anggota)
+func (h *TeamHandler) SetMembers(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body struct {
+		UserIDs []int64 `json:"user_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := h.svc.SetMembers(id, body.UserIDs); err != nil {
+		h.respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// GET /admin/targets/:type/:id  (type = breaking-news|category|product)
+func (h *TeamHandler) Targets(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	ids, err := h.svc.Targets(c.Param("type"), id)
+	if err != nil {
+		h.respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"team_ids": ids})
+}
+
+// PUT /admin/targets/:type/:id  {team_ids} ([] = untuk semua user)
+func (h *TeamHandler) SetTargets(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body struct {
+		TeamIDs []int64 `json:"team_ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := h.svc.SetTargets(c.Param("type"), id, body.TeamIDs); err != nil {
+		h.respondTeamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
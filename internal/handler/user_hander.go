@@ -3,7 +3,9 @@ package handler
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/service"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,10 +18,11 @@ func NewUserHandler(s *service.UserService) *UserHandler {
 	return &UserHandler{users: s}
 }
 
+// GET /admin/users?page=&page_size=&sort=id|username|name|created_at
 func (h *UserHandler) List(c *gin.Context) {
-	data, err := h.users.List()
+	data, err := h.users.List(pageRequest(c, models.SortID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, data)
@@ -39,8 +42,29 @@ func (h *UserHandler) Create(c *gin.Context) {
 
 	id, err := h.users.Create(body.Username, body.Name, body.Password, body.Role)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
+
+// POST /admin/users/:id/logout  {reason?}
+// cabut semua sesi user + tutup koneksi realtime-nya
+func (h *UserHandler) ForceLogout(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body) // body opsional
+
+	n, err := h.users.ForceLogout(id, body.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "revoked": n})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"cc-helper-backend/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audience mengisi "audience" (tim user login) untuk filter konten bertarget.
+// Dipasang sesudah Auth.
+func Audience(teamService *service.TeamService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		aud, err := teamService.AudienceFor(c.GetInt64("user_id"), c.GetString("role"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set("audience", aud)
+		c.Next()
+	}
+}
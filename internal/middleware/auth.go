@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"cc-helper-backend/internal/service"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -15,15 +16,55 @@ func Auth(authService *service.AuthService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 			return
 		}
-		token := strings.TrimPrefix(h, "Bearer ")
-		claims, err := authService.ParseToken(token)
+		authenticate(c, authService, strings.TrimPrefix(h, "Bearer "))
+	}
+}
+
+// AuthStream = Auth untuk stream /events: EventSource di browser tidak bisa
+// mengirim header, jadi diterima juga ?ticket= dari POST /events/ticket.
+// JWT sengaja tidak diterima di query (URL tercatat di log).
+func AuthStream(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h := c.GetHeader("Authorization"); strings.HasPrefix(h, "Bearer ") {
+			authenticate(c, authService, strings.TrimPrefix(h, "Bearer "))
+			return
+		}
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing ticket"})
+			return
+		}
+		claims, err := authService.RedeemStreamTicket(ticket)
+		if errors.Is(err, service.ErrSessionRevoked) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid ticket"})
 			return
 		}
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", string(claims.Role))
-		c.Next()
+		setClaims(c, claims)
+	}
+}
+
+// authenticate: token valid & sesinya belum dicabut (logout / revoke admin)
+func authenticate(c *gin.Context, authService *service.AuthService, token string) {
+	claims, err := authService.Authenticate(token)
+	if errors.Is(err, service.ErrSessionRevoked) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
 	}
+	setClaims(c, claims)
+}
+
+func setClaims(c *gin.Context, claims *service.Claims) {
+	c.Set("session_id", claims.SessionID)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", string(claims.Role))
+	c.Next()
 }
@@ -2,14 +2,16 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RequireRole(role string) gin.HandlerFunc {
+// RequireRole: user harus punya salah satu role yang disebut
+func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		r, _ := c.Get("role")
-		if r != role {
+		r := c.GetString("role")
+		if !slices.Contains(roles, r) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 			return
 		}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+type AttributeType string
+
+const (
+	AttributeNumber     AttributeType = "number"
+	AttributePercentage AttributeType = "percentage"
+	AttributeCurrency   AttributeType = "currency"
+	AttributeEnum       AttributeType = "enum"
+	AttributeDate       AttributeType = "date"
+)
+
+// CategoryAttribute = definisi 1 field atribut di kategori.
+// Berlaku juga untuk semua sub-kategori di bawahnya.
+type CategoryAttribute struct {
+	ID           int64         `json:"id"`
+	CategoryID   int64         `json:"category_id"`
+	Key          string        `json:"key"` // contoh: interest_rate
+	Label        string        `json:"label"`
+	Type         AttributeType `json:"type"`
+	Unit         *string       `json:"unit,omitempty"`    // contoh: "bulan"
+	Options      []string      `json:"options,omitempty"` // khusus enum
+	IsFilterable bool          `json:"is_filterable"`
+	SortOrder    int           `json:"sort_order"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// AttributeFilter dari query string:
+//
+//	attr.card_type=gold,platinum -> Values
+//	attr.interest_rate.min=5     -> Min
+//	attr.interest_rate.max=9     -> Max
+type AttributeFilter struct {
+	Key    string
+	Values []string
+	Min    *string
+	Max    *string
+}
+
+// AttributeStat = agregat nilai atribut per kategori dari hasil filter (bahan facet).
+// Nilai string (enum/date) dikelompokkan per nilai di Value; angka cuma Min/Max.
+type AttributeStat struct {
+	CategoryID int64
+	Key        string
+	Value      *string
+	Count      int
+	Min        *float64
+	Max        *float64
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facet = ringkasan nilai atribut dari hasil list (untuk UI filter)
+type Facet struct {
+	Key    string        `json:"key"`
+	Label  string        `json:"label"`
+	Type   AttributeType `json:"type"`
+	Unit   *string       `json:"unit,omitempty"`
+	Values []FacetValue  `json:"values,omitempty"` // enum
+	Min    any           `json:"min,omitempty"`    // number/percentage/currency/date
+	Max    any           `json:"max,omitempty"`
+	Count  int           `json:"count"` // jumlah item yang punya atribut ini
+}
@@ -2,16 +2,75 @@ package models
 
 import "time"
 
+// tingkat kepentingan breaking news; ticker agent menampilkan critical dulu
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// KindAnnouncement = breaking news berdiri sendiri (tidak terikat product)
+const KindAnnouncement ContentKind = "announcement"
+
 type BreakingNews struct {
 	ID        int64       `json:"id"`
-	Kind      ContentKind `json:"kind"`
-	ProductID int64       `json:"product_id"`
+	Kind      ContentKind `json:"kind"`                 // product / script / announcement
+	ProductID *int64      `json:"product_id,omitempty"` // kosong untuk announcement
 	Title     string      `json:"title"`
 	IsActive  bool        `json:"is_active"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	// IsMandatory: tiap agent wajib acknowledge (bukti sudah membaca)
+	IsMandatory bool `json:"is_mandatory"`
+	// Version naik tiap isi diubah; acknowledge berlaku untuk satu versi
+	Version   int        `json:"version"`
+	Severity  string     `json:"severity"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"` // NULL = langsung tayang
+	EndsAt    *time.Time `json:"ends_at,omitempty"`   // NULL = sampai dinonaktifkan
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// khusus announcement: isi pengumuman + link opsional (salah satu)
+	Body          []ContentBlock `json:"body,omitempty"`
+	LinkProductID *int64         `json:"link_product_id,omitempty"`
+	LinkS2NodeID  *int64         `json:"link_s2_node_id,omitempty"`
+
+	// khusus pemberitahuan update product: ringkasan perubahan + id diff
+	// (GET /content-changes/:id)
+	ChangeID      *int64         `json:"change_id,omitempty"`
+	ChangeSummary *ChangeSummary `json:"change_summary,omitempty"`
+
+	// computed: tujuan klik item ticker (product/script/s2), format sama
+	// dengan hasil search
+	Link      *SearchTarget `json:"link,omitempty"`
+	LinkLabel string        `json:"link_label,omitempty"`
 
 	// Optional join ke product untuk response
 	ProductSlug string   `json:"product_slug,omitempty"`
 	Product     *Product `json:"product,omitempty"`
 }
+
+// BreakingNewsAckSummary = satu baris laporan per item wajib
+type BreakingNewsAckSummary struct {
+	ID           int64       `json:"id"`
+	Kind         ContentKind `json:"kind"`
+	Title        string      `json:"title"`
+	Severity     string      `json:"severity"`
+	IsActive     bool        `json:"is_active"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Agents       int         `json:"agents"`       // jumlah agent yang wajib membaca
+	Acknowledged int         `json:"acknowledged"` // yang sudah acknowledge
+}
+
+// BreakingNewsAck = status acknowledge satu agent untuk satu item
+type BreakingNewsAck struct {
+	UserID         int64      `json:"user_id"`
+	Username       string     `json:"username"`
+	Name           string     `json:"name"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"` // null = belum
+}
+
+type BreakingNewsAckReport struct {
+	Item         *BreakingNews      `json:"item"`
+	Agents       int                `json:"agents"`
+	Acknowledged int                `json:"acknowledged"`
+	Users        []*BreakingNewsAck `json:"users"`
+}
@@ -0,0 +1,68 @@
+package models
+
+type LoanMethod string
+
+const (
+	LoanAnnuity LoanMethod = "annuity" // anuitas: cicilan tetap, bunga menurun
+	LoanFlat    LoanMethod = "flat"    // flat: bunga dihitung dari pokok awal
+)
+
+// atribut product yang dibaca kalkulator (lihat category_attributes)
+const (
+	AttrInterestRate   = "interest_rate"   // percentage, per tahun
+	AttrInterestMethod = "interest_method" // enum annuity/flat
+	AttrAdminFee       = "admin_fee"       // currency
+	AttrProvisionFee   = "provision_fee"   // percentage dari plafon
+	AttrTenorMin       = "tenor_min"       // number, bulan
+	AttrTenorMax       = "tenor_max"       // number, bulan
+	AttrPrincipalMin   = "principal_min"   // currency
+	AttrPrincipalMax   = "principal_max"   // currency
+)
+
+type LoanRequest struct {
+	ProductSlug     string     `json:"product_slug,omitempty"`
+	Principal       float64    `json:"principal"`
+	TenorMonths     int        `json:"tenor_months"`
+	Method          LoanMethod `json:"method,omitempty"`
+	AnnualRate      *float64   `json:"annual_rate,omitempty"` // override rate product
+	AsOf            string     `json:"as_of,omitempty"`       // tanggal pengajuan (YYYY-MM-DD), default hari ini
+	IncludeSchedule bool       `json:"include_schedule"`
+}
+
+// LoanCalcPreset dipakai step S2PASS (step_kind=calculator) untuk isian awal
+type LoanCalcPreset struct {
+	Principal   *float64    `json:"principal,omitempty"`
+	TenorMonths *int        `json:"tenor_months,omitempty"`
+	Method      *LoanMethod `json:"method,omitempty"`
+}
+
+type LoanFee struct {
+	Name      string  `json:"name"`
+	Amount    float64 `json:"amount"`
+	Formatted string  `json:"formatted"`
+}
+
+type LoanScheduleRow struct {
+	Month       int     `json:"month"`
+	Installment float64 `json:"installment"`
+	Principal   float64 `json:"principal"`
+	Interest    float64 `json:"interest"`
+	Balance     float64 `json:"balance"`
+}
+
+type LoanCalculation struct {
+	ProductSlug        string            `json:"product_slug,omitempty"`
+	ProductTitle       string            `json:"product_title,omitempty"`
+	Method             LoanMethod        `json:"method"`
+	Principal          float64           `json:"principal"`
+	TenorMonths        int               `json:"tenor_months"`
+	AnnualRate         float64           `json:"annual_rate"`
+	AsOf               string            `json:"as_of,omitempty"`
+	MonthlyInstallment float64           `json:"monthly_installment"`
+	TotalInterest      float64           `json:"total_interest"`
+	TotalFees          float64           `json:"total_fees"`
+	TotalPayment       float64           `json:"total_payment"`
+	Fees               []LoanFee         `json:"fees"`
+	Formatted          map[string]string `json:"formatted"` // nilai dalam format Rupiah
+	Schedule           []LoanScheduleRow `json:"schedule,omitempty"`
+}
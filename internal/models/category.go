@@ -3,10 +3,63 @@ package models
 import "time"
 
 type Category struct {
-	ID        int64       `json:"id"`
-	Kind      ContentKind `json:"kind"` // product / script
-	Name      string      `json:"name"`
-	ParentID  *int64      `json:"parent_id,omitempty"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID          int64       `json:"id"`
+	Kind        ContentKind `json:"kind"` // product / script
+	Name        string      `json:"name"`
+	ParentID    *int64      `json:"parent_id,omitempty"`
+	SortOrder   int         `json:"sort_order"`
+	Description *string     `json:"description,omitempty"`
+	Icon        *string     `json:"icon,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// computed (service): diisi di listing kategori
+	Counts *CategoryCounts `json:"counts,omitempty"`
+	// computed (service): hanya di endpoint tree
+	Children []*Category `json:"children,omitempty"`
+}
+
+type CategoryCounts struct {
+	Contents int `json:"contents"` // product/script di kategori ini + semua turunannya
+	Direct   int `json:"direct"`   // langsung di kategori ini
+	Children int `json:"children"` // sub-kategori langsung
+}
+
+type CategoryMergeResult struct {
+	SourceID        int64 `json:"source_id"`
+	TargetID        int64 `json:"target_id"`
+	MovedProducts   int   `json:"moved_products"`
+	MovedCategories int   `json:"moved_categories"`
+}
+
+// strategi hapus kategori (wajib dipilih)
+const (
+	CategoryDeleteBlock   = "block"   // gagal kalau masih ada sub-kategori/product
+	CategoryDeleteMove    = "move"    // product subtree pindah ke target, subtree dihapus
+	CategoryDeleteArchive = "archive" // kategori subtree & product-nya diarsip
+)
+
+// CategoryImpact = preview apa saja yang kena kalau kategori dihapus
+type CategoryImpact struct {
+	Category    *Category   `json:"category"`
+	Descendants []*Category `json:"descendants"` // semua turunan (tanpa kategori itu sendiri)
+	Products    []*Product  `json:"products"`    // product/script di kategori + turunannya
+	// yang sudah diarsip tidak ikut di list di atas, tapi tetap membuat
+	// strategi block gagal dan ikut terhapus / dipindah
+	ArchivedDescendants int `json:"archived_descendants"`
+	ArchivedProducts    int `json:"archived_products"`
+}
+
+// CategoryRestoreResult = hasil POST /admin/categories/:id/restore
+type CategoryRestoreResult struct {
+	RestoredCategories int `json:"restored_categories"`
+	RestoredProducts   int `json:"restored_products"`
+}
+
+type CategoryDeleteResult struct {
+	Strategy           string `json:"strategy"`
+	DeletedCategories  int    `json:"deleted_categories"`
+	MovedProducts      int    `json:"moved_products"`
+	ArchivedCategories int    `json:"archived_categories"`
+	ArchivedProducts   int    `json:"archived_products"`
 }
@@ -0,0 +1,34 @@
+package models
+
+type ComparedProduct struct {
+	ID           int64       `json:"id"`
+	Kind         ContentKind `json:"kind"`
+	Slug         string      `json:"slug"`
+	Title        string      `json:"title"`
+	CategoryPath string      `json:"category_path,omitempty"`
+}
+
+// ComparisonAttribute = 1 baris atribut, Values sejajar dengan urutan Products
+type ComparisonAttribute struct {
+	Key     string        `json:"key"`
+	Label   string        `json:"label"`
+	Type    AttributeType `json:"type"`
+	Unit    *string       `json:"unit,omitempty"`
+	Values  []any         `json:"values"`
+	Differs bool          `json:"differs"`
+}
+
+// ComparisonSection = bagian konten dengan judul (heading) yang sama,
+// Contents berisi HTML per product (null kalau product tsb tidak punya bagian ini)
+type ComparisonSection struct {
+	Key      string    `json:"key"`
+	Title    string    `json:"title"`
+	Contents []*string `json:"contents"`
+	Differs  bool      `json:"differs"`
+}
+
+type ProductComparison struct {
+	Products   []ComparedProduct      `json:"products"`
+	Attributes []*ComparisonAttribute `json:"attributes"`
+	Sections   []*ComparisonSection   `json:"sections"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// operasi per blok pada diff konten
+const (
+	BlockUnchanged = "unchanged"
+	BlockAdded     = "added"
+	BlockRemoved   = "removed"
+	BlockChanged   = "changed"
+)
+
+// BlockDiff = satu baris diff blok. OldIndex/NewIndex = posisi blok di
+// versi lama/baru (nil kalau tidak ada di versi itu).
+type BlockDiff struct {
+	Op       string        `json:"op"`
+	OldIndex *int          `json:"old_index,omitempty"`
+	NewIndex *int          `json:"new_index,omitempty"`
+	Old      *ContentBlock `json:"old,omitempty"`
+	New      *ContentBlock `json:"new,omitempty"`
+	Label    string        `json:"label"` // cuplikan teks / alt gambar
+}
+
+// ChangeSummary = ringkasan otomatis perubahan (ditampilkan di ticker)
+type ChangeSummary struct {
+	TitleChanged bool     `json:"title_changed"`
+	Added        int      `json:"added"`
+	Removed      int      `json:"removed"`
+	Changed      int      `json:"changed"`
+	Items        []string `json:"items"` // "Diubah: Syarat pengajuan ..."
+	Text         string   `json:"text"`  // "2 blok diubah, 1 blok ditambah"
+}
+
+// ContentChange = snapshot update product yang diumumkan
+type ContentChange struct {
+	ID           int64          `json:"id"`
+	ProductID    int64          `json:"product_id"`
+	TitleBefore  string         `json:"title_before"`
+	TitleAfter   string         `json:"title_after"`
+	BlocksBefore []ContentBlock `json:"blocks_before"`
+	BlocksAfter  []ContentBlock `json:"blocks_after"`
+	Summary      ChangeSummary  `json:"summary"`
+	CreatedBy    *int64         `json:"created_by,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+
+	// computed (service)
+	Product *SearchTarget `json:"product,omitempty"`
+	Diff    []BlockDiff   `json:"diff,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// jenis event di stream /events (SSE "event:" field)
+const (
+	EventBreakingNews = "breaking_news" // breaking news / announcement dibuat, diubah, dihapus
+	EventProduct      = "product"       // product/script dibuat, diubah, dihapus
+	EventLogout       = "logout"        // sesi user dipaksa keluar
+	EventResync       = "resync"        // Last-Event-ID di luar buffer / target tim berubah: client fetch ulang
+)
+
+// aksi pada ChangeEvent
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+type Event struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	Data   any    `json:"data"`
+	UserID int64  `json:"-"` // 0 = semua user, selain itu hanya user ini
+	// SessionID != 0 = hanya koneksi yang dibuka dengan sesi ini
+	SessionID int64 `json:"-"`
+}
+
+// ChangeEvent = payload event breaking_news & product. Client cukup
+// refetch item/list terkait (yang sudah difilter per tim); judul & slug
+// sengaja tidak ikut karena event dikirim ke semua user.
+type ChangeEvent struct {
+	Action   string      `json:"action"`
+	ID       int64       `json:"id"`
+	Kind     ContentKind `json:"kind,omitempty"`
+	Severity string      `json:"severity,omitempty"`
+}
+
+// LogoutEvent: Reason kosong = logout biasa dari sesi itu sendiri
+type LogoutEvent struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import "strings"
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Pilihan sort list endpoint (?sort=title / ?sort=-updated_at untuk DESC)
+const (
+	SortRelevance  = "relevance" // hanya kalau ada q
+	SortTitle      = "title"
+	SortUpdatedAt  = "updated_at"
+	SortCreatedAt  = "created_at"
+	SortPopularity = "popularity" // jumlah klik dari hasil search (90 hari)
+	SortID         = "id"
+	SortUsername   = "username"
+	SortName       = "name"
+	SortLabel      = "label"
+	SortOrder      = "sort_order"
+	SortSeverity   = "severity"
+)
+
+// PageRequest = parameter paging + sort dari query string
+type PageRequest struct {
+	Page     int
+	PageSize int
+	Sort     string
+	Desc     bool
+}
+
+// ParsePageRequest: page mulai 1, page_size dibatasi MaxPageSize,
+// sort kosong -> defaultSort (boleh diawali "-").
+func ParsePageRequest(page, pageSize int, sort, defaultSort string) PageRequest {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	pageSize = min(pageSize, MaxPageSize)
+
+	sort = strings.TrimSpace(sort)
+	if sort == "" {
+		sort = defaultSort
+	}
+	req := PageRequest{Page: page, PageSize: pageSize}
+	req.Desc = strings.HasPrefix(sort, "-")
+	req.Sort = strings.TrimPrefix(sort, "-")
+	return req
+}
+
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// SortParam = bentuk query string-nya lagi ("-updated_at")
+func (p PageRequest) SortParam() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+// OrderDir = "ASC" / "DESC" untuk dipakai di ORDER BY
+func (p PageRequest) OrderDir() string {
+	if p.Desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// Page = envelope response list endpoint
+type Page[T any] struct {
+	Items    []T    `json:"items"`
+	Total    int    `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Sort     string `json:"sort"`
+}
+
+func NewPage[T any](items []T, total int, req PageRequest) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &Page[T]{
+		Items:    items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Sort:     req.SortParam(),
+	}
+}
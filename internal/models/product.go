@@ -30,9 +30,31 @@ type Product struct {
 	Title      string         `json:"title"`
 	CategoryID int64          `json:"categoryId"`
 	Blocks     []ContentBlock `json:"blocks"`
+	Attributes map[string]any `json:"attributes"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 
 	// computed (service)
 	CategoryPath string `json:"category_path,omitempty"` // contoh: "Informasi / Kredit / KGB / PISAN"
+	// sumber search_vector, diisi service sebelum Create/Update dan ditulis
+	// di transaksi yang sama
+	SearchBody string `json:"-"`
+	SearchPath string `json:"-"`
+}
+
+// ProductFilter = parameter list product/script di repository
+type ProductFilter struct {
+	Kind       ContentKind
+	Q          string
+	CategoryID *int64
+	// ExactCategory: true = hanya kategori itu saja; default ikut semua
+	// turunannya (subtree)
+	ExactCategory bool
+	Attributes    []AttributeFilter
+	Sort          string // Sort* (kosong = relevance kalau ada q, selain itu title)
+	Desc          bool
+	Limit         int
+	Offset        int
+	// Audience membatasi ke konten yang boleh dilihat tim user
+	Audience Audience
 }
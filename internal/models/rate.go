@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RateTable = tabel nilai (bunga, biaya, dst) yang berubah per tanggal berlaku
+type RateTable struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	Key       string    `json:"key"`
+	Label     string    `json:"label"`
+	Unit      *string   `json:"unit,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// diisi service
+	Current *RateValue  `json:"current,omitempty"`
+	Values  []RateValue `json:"values,omitempty"`
+}
+
+// RateValue: tanggal format YYYY-MM-DD, ValidTo inklusif (nil = open-ended)
+type RateValue struct {
+	ID        int64   `json:"id"`
+	Value     float64 `json:"value"`
+	ValidFrom string  `json:"valid_from"`
+	ValidTo   *string `json:"valid_to"`
+	Note      *string `json:"note,omitempty"`
+}
@@ -21,9 +21,10 @@ const (
 type S2StepKind string
 
 const (
-	S2StepScript S2StepKind = "script" // body HTML/text
-	S2StepInput  S2StepKind = "input"  // input field (nama nasabah, dll)
-	S2StepLink   S2StepKind = "link"   // link ke product/script (pakai link_kind+link_slug)
+	S2StepScript S2StepKind = "script"     // body HTML/text
+	S2StepInput  S2StepKind = "input"      // input field (nama nasabah, dll)
+	S2StepLink   S2StepKind = "link"       // link ke product/script (pakai link_kind+link_slug)
+	S2StepCalc   S2StepKind = "calculator" // kalkulator cicilan product (link_slug + calc_preset)
 )
 
 type S2LinkKind string
@@ -60,6 +61,9 @@ type S2Node struct {
 	LinkKind *S2LinkKind `json:"link_kind,omitempty"`
 	LinkSlug *string     `json:"link_slug,omitempty"`
 
+	// isian awal kalkulator untuk step_kind=calculator
+	CalcPreset *LoanCalcPreset `json:"calc_preset,omitempty"`
+
 	SortOrder int       `json:"sort_order"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -0,0 +1,192 @@
+package models
+
+import "time"
+
+type GlossaryTerm struct {
+	ID          int64     `json:"id"`
+	Term        string    `json:"term"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const (
+	SearchTypeProduct  = "product"
+	SearchTypeScript   = "script"
+	SearchTypeCategory = "category"
+	SearchTypeS2       = "s2"
+)
+
+// SearchOptions = filter unified search
+type SearchOptions struct {
+	Types      []string // kosong = semua type
+	CategoryID *int64   // batasi ke subtree kategori ini (product/script/category)
+	Audience   Audience // konten bertarget tim
+	UserID     int64    // pemilik query (riwayat autocomplete per user)
+}
+
+func (o SearchOptions) Wants(t string) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, x := range o.Types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchTarget = tujuan navigasi di SPA saat hasil search diklik
+type SearchTarget struct {
+	Type       string      `json:"type"` // product/script/category/s2
+	ID         int64       `json:"id"`
+	Slug       string      `json:"slug,omitempty"`
+	Kind       ContentKind `json:"kind,omitempty"`
+	MainType   S2MainType  `json:"main_type,omitempty"`
+	Path       string      `json:"path,omitempty"`       // path kategori
+	Breadcrumb []string    `json:"breadcrumb,omitempty"` // label node S2 dari root
+}
+
+// SearchHit = product/script hasil full-text search + skor & cuplikan.
+// Snippet & TitleHighlight sudah di-escape, kata yang cocok dibungkus <mark>.
+type SearchHit struct {
+	*Product
+	Rank           float64       `json:"rank"`
+	Snippet        string        `json:"snippet"`
+	TitleHighlight string        `json:"title_highlight"`
+	Target         *SearchTarget `json:"target"`
+	Promoted       bool          `json:"promoted"`
+}
+
+type CategoryHit struct {
+	*Category
+	Path   string        `json:"path"`
+	Rank   float64       `json:"rank"`
+	Target *SearchTarget `json:"target"`
+}
+
+type S2NodeHit struct {
+	*S2Node
+	Breadcrumb []string      `json:"breadcrumb"`
+	Rank       float64       `json:"rank"`
+	Target     *SearchTarget `json:"target"`
+}
+
+// SearchResult dikelompokkan per type
+type SearchResult struct {
+	Products   []*SearchHit   `json:"products"`
+	Scripts    []*SearchHit   `json:"scripts"`
+	Categories []*CategoryHit `json:"categories"`
+	S2Nodes    []*S2NodeHit   `json:"s2_nodes"`
+
+	// DidYouMean diisi kalau query asli tidak menemukan apa-apa dan ada
+	// ejaan yang mirip; Fuzzy=true kalau hasil berasal dari fallback.
+	DidYouMean string `json:"did_you_mean,omitempty"`
+	Fuzzy      bool   `json:"fuzzy"`
+
+	// QueryID dikirim balik lewat POST /search/click saat hasil diklik
+	QueryID int64 `json:"query_id,omitempty"`
+}
+
+type Synonym struct {
+	ID            int64     `json:"id"`
+	Term          string    `json:"term"` // contoh: "kpr"
+	Synonyms      []string  `json:"synonyms"`
+	Bidirectional bool      `json:"bidirectional"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// QueryExpansion = hasil ekspansi query oleh kamus sinonim.
+// Groups sejajar dengan kata/frasa query, tiap group berisi alternatifnya.
+type QueryExpansion struct {
+	Query   string     `json:"query"`
+	Groups  [][]string `json:"groups"`
+	TSQuery string     `json:"tsquery"`
+}
+
+// AutocompleteItem = satu saran typeahead. Saran dari query terakhir
+// tidak punya Target (dipakai sebagai isi kotak search).
+type AutocompleteItem struct {
+	Text   string        `json:"text"`
+	Target *SearchTarget `json:"target,omitempty"`
+}
+
+type AutocompleteResult struct {
+	Titles     []AutocompleteItem `json:"titles"`
+	Categories []AutocompleteItem `json:"categories"`
+	Queries    []AutocompleteItem `json:"queries"`
+}
+
+// ===== Analytics =====
+
+const (
+	SearchSourceSearch   = "search"
+	SearchSourceProducts = "products"
+	SearchSourceScripts  = "scripts"
+)
+
+type SearchQueryLog struct {
+	ID          int64     `json:"id"`
+	UserID      *int64    `json:"user_id,omitempty"`
+	Query       string    `json:"query"`
+	Normalized  string    `json:"normalized"`
+	Source      string    `json:"source"`
+	ResultCount int       `json:"result_count"`
+	Fuzzy       bool      `json:"fuzzy"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SearchReportRange = periode laporan, To inklusif (per hari)
+type SearchReportRange struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// SearchQueryStat = agregat per query (normalized) dalam periode laporan
+type SearchQueryStat struct {
+	Query        string    `json:"query"`
+	Searches     int       `json:"searches"`
+	Users        int       `json:"users"`
+	ZeroResults  int       `json:"zero_results"`
+	Clicks       int       `json:"clicks"`
+	AvgResults   float64   `json:"avg_results"`
+	LastSearched time.Time `json:"last_searched"`
+}
+
+type SearchTrendPoint struct {
+	Period      string `json:"period"` // YYYY-MM-DD (awal hari/minggu)
+	Searches    int    `json:"searches"`
+	Users       int    `json:"users"`
+	ZeroResults int    `json:"zero_results"`
+	Clicks      int    `json:"clicks"`
+}
+
+// ===== Promoted results (best bets) =====
+
+type PromotionMatch string
+
+const (
+	PromotionExact    PromotionMatch = "exact"
+	PromotionPrefix   PromotionMatch = "prefix"
+	PromotionContains PromotionMatch = "contains"
+)
+
+type SearchPromotion struct {
+	ID        int64          `json:"id"`
+	Pattern   string         `json:"pattern"`
+	MatchType PromotionMatch `json:"match_type"`
+	ProductID int64          `json:"product_id"`
+	Position  int            `json:"position"`
+	Note      *string        `json:"note,omitempty"`
+	StartsAt  *time.Time     `json:"starts_at,omitempty"`
+	EndsAt    *time.Time     `json:"ends_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	// dari join products (list admin)
+	ProductKind  ContentKind `json:"product_kind,omitempty"`
+	ProductSlug  string      `json:"product_slug,omitempty"`
+	ProductTitle string      `json:"product_title,omitempty"`
+}
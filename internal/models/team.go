@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type Team struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	MemberIDs []int64   `json:"member_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// jenis konten yang bisa ditarget ke tim (path /admin/targets/:type/:id)
+const (
+	TargetBreakingNews = "breaking-news"
+	TargetCategory     = "category" // berlaku untuk seluruh subtree
+	TargetProduct      = "product"  // product & script
+)
+
+// TeamTarget = satu konten yang ditarget ke tim (untuk cek sebelum hapus tim)
+type TeamTarget struct {
+	Type  string `json:"type"` // models.Target*
+	ID    int64  `json:"id"`
+	Label string `json:"label"`
+}
+
+// Audience = siapa yang sedang melihat konten bertarget.
+// All = admin/supervisor (semua konten), selain itu hanya konten tanpa
+// target atau yang targetnya salah satu TeamIDs.
+type Audience struct {
+	All     bool
+	TeamIDs []int64
+}
+
+// AudienceAll dipakai untuk proses internal & admin
+var AudienceAll = Audience{All: true}
+
+// AudienceRules = semua target tim (untuk filter in-memory, mis. autocomplete)
+type AudienceRules struct {
+	Products   map[int64][]int64
+	Categories map[int64][]int64
+}
+
+// Allows: target kosong = semua boleh
+func (a Audience) Allows(targets []int64) bool {
+	if a.All || len(targets) == 0 {
+		return true
+	}
+	for _, t := range targets {
+		for _, id := range a.TeamIDs {
+			if t == id {
+				return true
+			}
+		}
+	}
+	return false
+}
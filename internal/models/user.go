@@ -5,8 +5,9 @@ import "time"
 type Role string
 
 const (
-	RoleAdmin Role = "admin"
-	RoleAgent Role = "agent"
+	RoleAdmin      Role = "admin"
+	RoleSupervisor Role = "supervisor" // laporan tim (acknowledgement dll)
+	RoleAgent      Role = "agent"
 )
 
 type User struct {
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+type AttributeRepository interface {
+	Create(a *models.CategoryAttribute) (int64, error)
+	Update(a *models.CategoryAttribute) error
+	Delete(id int64) error
+	GetByID(id int64) (*models.CategoryAttribute, error)
+	ListByCategories(categoryIDs []int64) ([]*models.CategoryAttribute, error)
+}
+
+type attributeRepository struct {
+	db *sql.DB
+}
+
+func NewAttributeRepository(db *sql.DB) AttributeRepository {
+	return &attributeRepository{db: db}
+}
+
+func (r *attributeRepository) Create(a *models.CategoryAttribute) (int64, error) {
+	options, err := marshalOptions(a.Options)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = r.db.QueryRow(`
+		INSERT INTO category_attributes (category_id, key, label, type, unit, options, is_filterable, sort_order)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id
+	`, a.CategoryID, a.Key, a.Label, a.Type, a.Unit, options, a.IsFilterable, a.SortOrder).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *attributeRepository) Update(a *models.CategoryAttribute) error {
+	options, err := marshalOptions(a.Options)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE category_attributes
+		SET label = $1,
+			type = $2,
+			unit = $3,
+			options = $4,
+			is_filterable = $5,
+			sort_order = $6,
+			updated_at = NOW()
+		WHERE id = $7
+	`, a.Label, a.Type, a.Unit, options, a.IsFilterable, a.SortOrder, a.ID)
+	return err
+}
+
+func (r *attributeRepository) Delete(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM category_attributes WHERE id = $1`, id)
+	return err
+}
+
+func (r *attributeRepository) GetByID(id int64) (*models.CategoryAttribute, error) {
+	row := r.db.QueryRow(`
+		SELECT id, category_id, key, label, type, unit, options, is_filterable, sort_order, created_at, updated_at
+		FROM category_attributes
+		WHERE id = $1
+	`, id)
+	return scanAttribute(row)
+}
+
+func (r *attributeRepository) ListByCategories(categoryIDs []int64) ([]*models.CategoryAttribute, error) {
+	rows, err := r.db.Query(`
+		SELECT id, category_id, key, label, type, unit, options, is_filterable, sort_order, created_at, updated_at
+		FROM category_attributes
+		WHERE category_id = ANY($1)
+		ORDER BY sort_order, lower(label)
+	`, pq.Array(categoryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.CategoryAttribute
+	for rows.Next() {
+		a, err := scanAttribute(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+func scanAttribute(row scanner) (*models.CategoryAttribute, error) {
+	var (
+		a       models.CategoryAttribute
+		unit    sql.NullString
+		options []byte
+	)
+	if err := row.Scan(
+		&a.ID, &a.CategoryID, &a.Key, &a.Label, &a.Type, &unit, &options,
+		&a.IsFilterable, &a.SortOrder, &a.CreatedAt, &a.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if unit.Valid {
+		u := unit.String
+		a.Unit = &u
+	}
+	if len(options) > 0 {
+		_ = json.Unmarshal(options, &a.Options)
+	}
+	return &a, nil
+}
+
+func marshalOptions(options []string) (any, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
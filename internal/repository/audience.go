@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// audienceParam: NULL = lihat semua, selain itu bigint[] team user
+// (array kosong = hanya konten tanpa target)
+func audienceParam(a models.Audience) any {
+	if a.All {
+		return nil
+	}
+	ids := a.TeamIDs
+	if ids == nil {
+		ids = []int64{}
+	}
+	return pq.Array(ids)
+}
+
+// targetVisibleSQL: baris tanpa target, atau salah satu targetnya ada di param
+func targetVisibleSQL(table, col, idExpr, param string) string {
+	return `(NOT EXISTS (SELECT 1 FROM ` + table + ` tt WHERE tt.` + col + ` = ` + idExpr + `)
+		OR EXISTS (SELECT 1 FROM ` + table + ` tt WHERE tt.` + col + ` = ` + idExpr + ` AND tt.team_id = ANY(` + param + `::bigint[])))`
+}
+
+// hiddenCategoriesSQL = subquery id kategori yang tertutup untuk param:
+// kategori bertarget tanpa tim yang cocok + semua turunannya
+func hiddenCategoriesSQL(param string) string {
+	return `
+		WITH RECURSIVE hidden AS (
+			SELECT c.id FROM categories c
+			WHERE NOT ` + targetVisibleSQL("category_teams", "category_id", "c.id", param) + `
+			UNION
+			SELECT c.id FROM categories c JOIN hidden h ON c.parent_id = h.id
+		)
+		SELECT id FROM hidden`
+}
+
+// categoryVisibleSQL: idExpr = kolom id kategori
+func categoryVisibleSQL(idExpr, param string) string {
+	return `(` + param + `::bigint[] IS NULL OR ` + idExpr + ` NOT IN (` + hiddenCategoriesSQL(param) + `))`
+}
+
+// productVisibleSQL: target product sendiri + kategori (dan leluhurnya)
+func productVisibleSQL(idExpr, categoryExpr, param string) string {
+	return `(` + param + `::bigint[] IS NULL OR (` +
+		targetVisibleSQL("product_teams", "product_id", idExpr, param) +
+		` AND ` + categoryExpr + ` NOT IN (` + hiddenCategoriesSQL(param) + `)))`
+}
+
+// breakingNewsVisibleSQL (alias b): target item sendiri + product-nya
+// (breaking news product bertarget ikut tersembunyi)
+func breakingNewsVisibleSQL(param string) string {
+	return `(` + param + `::bigint[] IS NULL OR (` +
+		targetVisibleSQL("breaking_news_teams", "breaking_news_id", "b.id", param) + `
+		AND (b.product_id IS NULL OR EXISTS (
+			SELECT 1 FROM products bp
+			WHERE bp.id = b.product_id AND ` + productVisibleSQL("bp.id", "bp.category_id", param) + `))))`
+}
+
+// breakingNewsAudienceSQL: user userExpr termasuk sasaran item bnExpr
+// (item tanpa target = semua user)
+func breakingNewsAudienceSQL(bnExpr, userExpr string) string {
+	return `(NOT EXISTS (SELECT 1 FROM breaking_news_teams bt WHERE bt.breaking_news_id = ` + bnExpr + `)
+		OR EXISTS (SELECT 1 FROM breaking_news_teams bt JOIN user_teams ut ON ut.team_id = bt.team_id
+		           WHERE bt.breaking_news_id = ` + bnExpr + ` AND ut.user_id = ` + userExpr + `))`
+}
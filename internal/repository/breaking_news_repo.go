@@ -3,13 +3,38 @@ package repository
 import (
 	"cc-helper-backend/internal/models"
 	"database/sql"
+	"encoding/json"
+	"strconv"
 )
 
 type BreakingNewsRepository interface {
 	Create(b *models.BreakingNews) (int64, error)
-	ListActive() ([]*models.BreakingNews, error)
-	ListAll() ([]*models.BreakingNews, error)
+	// Update mengubah title, status, severity, jadwal + body & link announcement
+	// (sql.ErrNoRows kalau id tidak ada). Version naik kalau judul/body/link berubah.
+	Update(b *models.BreakingNews) error
+	GetByID(id int64) (*models.BreakingNews, error)
+	// Visible: item boleh dilihat aud (target item & product-nya)
+	Visible(id int64, aud models.Audience) (bool, error)
+	// ListActive & ListUnacknowledged hanya item yang boleh dilihat aud
+	ListActive(aud models.Audience, page models.PageRequest) ([]*models.BreakingNews, int, error)
+	ListAll(page models.PageRequest) ([]*models.BreakingNews, int, error)
 	Delete(id int64) error
+
+	// Acknowledge idempotent per versi item: acknowledge kedua kali untuk
+	// versi yang sama tidak mengubah waktunya
+	Acknowledge(id, userID int64) error
+	// HasAcks / ProductHasAcks: sudah ada catatan acknowledgement (item
+	// tidak boleh dihapus, cukup dinonaktifkan)
+	HasAcks(id int64) (bool, error)
+	ProductHasAcks(productID int64) (bool, error)
+	// ListUnacknowledged = item wajib yang sedang tayang & versi terbarunya belum di-acknowledge userID
+	ListUnacknowledged(userID int64, aud models.Audience, page models.PageRequest) ([]*models.BreakingNews, int, error)
+	// AckSummary & AckUsers hanya menghitung agent yang jadi sasaran item
+	// (anggota tim target, atau semua agent kalau tanpa target) dan
+	// acknowledgement untuk versi terbaru item
+	AckSummary() ([]*models.BreakingNewsAckSummary, error)
+	// AckUsers = status agent sasaran untuk satu item, yang belum dulu
+	AckUsers(id int64) ([]*models.BreakingNewsAck, error)
 }
 
 type breakingNewsRepository struct {
@@ -21,123 +46,375 @@ func NewBreakingNewsRepository(db *sql.DB) BreakingNewsRepository {
 }
 
 func (r *breakingNewsRepository) Create(b *models.BreakingNews) (int64, error) {
+	if b.Severity == "" {
+		b.Severity = models.SeverityInfo
+	}
 	var id int64
 	err := r.db.QueryRow(`
-        INSERT INTO breaking_news (kind, product_id, title, is_active)
-        VALUES ($1,$2,$3,$4)
+        INSERT INTO breaking_news (kind, product_id, title, is_active, severity, starts_at, ends_at,
+                                   body, link_product_id, link_s2_node_id, is_mandatory, change_id)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
         RETURNING id
-    `, b.Kind, b.ProductID, b.Title, b.IsActive).Scan(&id)
+    `, b.Kind, b.ProductID, b.Title, b.IsActive, b.Severity, b.StartsAt, b.EndsAt,
+		marshalBody(b.Body), b.LinkProductID, b.LinkS2NodeID, b.IsMandatory, b.ChangeID).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
-// ==== LIST ACTIVE UNTUK TICKER ====
+func (r *breakingNewsRepository) Update(b *models.BreakingNews) error {
+	res, err := r.db.Exec(`
+        UPDATE breaking_news
+        SET title = $1,
+            is_active = $2,
+            severity = $3,
+            starts_at = $4,
+            ends_at = $5,
+            body = $6,
+            link_product_id = $7,
+            link_s2_node_id = $8,
+            is_mandatory = $9,
+            version = version + CASE
+                WHEN title IS DISTINCT FROM $1 OR body IS DISTINCT FROM $6
+                  OR link_product_id IS DISTINCT FROM $7 OR link_s2_node_id IS DISTINCT FROM $8
+                THEN 1 ELSE 0 END,
+            updated_at = NOW()
+        WHERE id = $10
+    `, b.Title, b.IsActive, b.Severity, b.StartsAt, b.EndsAt,
+		marshalBody(b.Body), b.LinkProductID, b.LinkS2NodeID, b.IsMandatory, b.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
 
-func (r *breakingNewsRepository) ListActive() ([]*models.BreakingNews, error) {
-	rows, err := r.db.Query(`
-        SELECT 
+// breakingNewsSelect: param = audience (lihat audienceParam, "NULL" = semua);
+// link ke product yang tertutup untuk audience ikut dikosongkan
+func breakingNewsSelect(param string) string {
+	return `
+        SELECT
             b.id,
             b.kind,
             b.product_id,
             b.title,
             b.is_active,
+            b.is_mandatory,
+            b.severity,
+            b.starts_at,
+            b.ends_at,
             b.created_at,
             b.updated_at,
+            b.body,
+            b.link_product_id,
+            b.link_s2_node_id,
             p.slug,
             p.title,
-            p.kind
+            p.kind,
+            lp.slug,
+            lp.title,
+            lp.kind,
+            sn.label,
+            sn.main_type,
+            b.change_id,
+            cc.summary,
+            b.version
         FROM breaking_news b
-        JOIN products p ON p.id = b.product_id
+        LEFT JOIN products p ON p.id = b.product_id
+        LEFT JOIN products lp ON lp.id = b.link_product_id AND lp.archived_at IS NULL
+             AND ` + productVisibleSQL("lp.id", "lp.category_id", param) + `
+        LEFT JOIN s2_nodes sn ON sn.id = b.link_s2_node_id
+        LEFT JOIN content_changes cc ON cc.id = b.change_id
+`
+}
+
+func (r *breakingNewsRepository) GetByID(id int64) (*models.BreakingNews, error) {
+	return scanBreakingNews(r.db.QueryRow(breakingNewsSelect("NULL")+`WHERE b.id = $1`, id))
+}
+
+func (r *breakingNewsRepository) Visible(id int64, aud models.Audience) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM breaking_news b WHERE b.id = $1 AND `+breakingNewsVisibleSQL("$2")+`)
+    `, id, audienceParam(aud)).Scan(&ok)
+	return ok, err
+}
+
+// ==== LIST ACTIVE UNTUK TICKER ====
+
+// activeBreakingNewsWhere: aktif, dalam jadwal tayang & product-nya (kalau ada)
+// tidak diarsip
+const activeBreakingNewsWhere = `
         WHERE b.is_active = TRUE
-        ORDER BY b.created_at DESC
-    `)
+          AND (b.starts_at IS NULL OR b.starts_at <= NOW())
+          AND (b.ends_at IS NULL OR b.ends_at > NOW())
+          AND (b.product_id IS NULL OR b.product_id IN (SELECT id FROM products WHERE archived_at IS NULL))
+`
+
+// ticker: yang paling penting selalu di depan, baru urutan pilihan
+func (r *breakingNewsRepository) ListActive(aud models.Audience, page models.PageRequest) ([]*models.BreakingNews, int, error) {
+	where := activeBreakingNewsWhere + " AND " + breakingNewsVisibleSQL("$1")
+	return r.list(where, []any{audienceParam(aud)}, "$1", severityRankSQL+" DESC, ", page)
+}
+
+// ==== LIST ALL UNTUK ADMIN ====
+
+func (r *breakingNewsRepository) ListAll(page models.PageRequest) ([]*models.BreakingNews, int, error) {
+	return r.list("", nil, "NULL", "", page)
+}
+
+const severityRankSQL = `CASE b.severity WHEN 'critical' THEN 2 WHEN 'warning' THEN 1 ELSE 0 END`
+
+// list: placeholder di where mulai dari $1 (args), limit/offset menyusul.
+// audParam = placeholder audience di args (atau "NULL") untuk link product.
+func (r *breakingNewsRepository) list(where string, args []any, audParam, orderPrefix string, page models.PageRequest) ([]*models.BreakingNews, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM breaking_news b `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "b.created_at " + page.OrderDir()
+	switch page.Sort {
+	case models.SortTitle:
+		order = "lower(b.title) " + page.OrderDir()
+	case models.SortSeverity:
+		order = severityRankSQL + " " + page.OrderDir() + ", b.created_at DESC"
+	}
+	n := len(args)
+	rows, err := r.db.Query(breakingNewsSelect(audParam)+where+`
+        ORDER BY `+orderPrefix+order+`, b.id DESC
+        LIMIT $`+strconv.Itoa(n+1)+` OFFSET $`+strconv.Itoa(n+2)+`
+    `, append(args, page.PageSize, page.Offset())...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var result []*models.BreakingNews
 	for rows.Next() {
-		var (
-			b models.BreakingNews
-			p models.Product
-		)
-		if err := rows.Scan(
-			&b.ID,
-			&b.Kind,
-			&b.ProductID,
-			&b.Title,
-			&b.IsActive,
-			&b.CreatedAt,
-			&b.UpdatedAt,
-			&p.Slug,
-			&p.Title,
-			&p.Kind,
-		); err != nil {
-			return nil, err
+		b, err := scanBreakingNews(rows)
+		if err != nil {
+			return nil, 0, err
 		}
+		result = append(result, b)
+	}
+	return result, total, nil
+}
+
+func scanBreakingNews(row scanner) (*models.BreakingNews, error) {
+	var (
+		b                       models.BreakingNews
+		productID, linkProduct  sql.NullInt64
+		linkS2                  sql.NullInt64
+		startsAt, ends          sql.NullTime
+		body                    []byte
+		pSlug, pTitle, pKind    sql.NullString
+		lpSlug, lpTitle, lpKind sql.NullString
+		s2Label, s2MainType     sql.NullString
+		changeID                sql.NullInt64
+		changeSummary           []byte
+	)
+	if err := row.Scan(
+		&b.ID,
+		&b.Kind,
+		&productID,
+		&b.Title,
+		&b.IsActive,
+		&b.IsMandatory,
+		&b.Severity,
+		&startsAt,
+		&ends,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+		&body,
+		&linkProduct,
+		&linkS2,
+		&pSlug,
+		&pTitle,
+		&pKind,
+		&lpSlug,
+		&lpTitle,
+		&lpKind,
+		&s2Label,
+		&s2MainType,
+		&changeID,
+		&changeSummary,
+		&b.Version,
+	); err != nil {
+		return nil, err
+	}
+	if startsAt.Valid {
+		t := startsAt.Time
+		b.StartsAt = &t
+	}
+	if ends.Valid {
+		t := ends.Time
+		b.EndsAt = &t
+	}
+	if len(body) > 0 {
+		_ = json.Unmarshal(body, &b.Body)
+	}
+	if changeID.Valid {
+		id := changeID.Int64
+		b.ChangeID = &id
+		if len(changeSummary) > 0 {
+			b.ChangeSummary = &models.ChangeSummary{}
+			_ = json.Unmarshal(changeSummary, b.ChangeSummary)
+		}
+	}
 
+	switch {
+	case productID.Valid:
+		id := productID.Int64
+		p := &models.Product{ID: id, Slug: pSlug.String, Title: pTitle.String, Kind: models.ContentKind(pKind.String)}
+		b.ProductID = &id
 		b.ProductSlug = p.Slug
-		b.Product = &p
-		result = append(result, &b)
+		b.Product = p
+		b.Link = &models.SearchTarget{Type: string(p.Kind), ID: id, Slug: p.Slug, Kind: p.Kind}
+		b.LinkLabel = p.Title
+	case linkProduct.Valid:
+		id := linkProduct.Int64
+		b.LinkProductID = &id
+		// product link yang sudah diarsip / tertutup untuk audience tidak ditampilkan
+		if lpSlug.Valid {
+			kind := models.ContentKind(lpKind.String)
+			b.Link = &models.SearchTarget{Type: string(kind), ID: id, Slug: lpSlug.String, Kind: kind}
+			b.LinkLabel = lpTitle.String
+		}
+	case linkS2.Valid:
+		id := linkS2.Int64
+		b.LinkS2NodeID = &id
+		b.Link = &models.SearchTarget{Type: models.SearchTypeS2, ID: id, MainType: models.S2MainType(s2MainType.String)}
+		b.LinkLabel = s2Label.String
 	}
-	return result, nil
+	return &b, nil
 }
 
-// ==== LIST ALL UNTUK ADMIN ====
+// marshalBody: body kosong disimpan NULL
+func marshalBody(blocks []models.ContentBlock) any {
+	if len(blocks) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(blocks)
+	return b
+}
+
+func (r *breakingNewsRepository) Delete(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM breaking_news WHERE id = $1`, id)
+	return err
+}
 
-func (r *breakingNewsRepository) ListAll() ([]*models.BreakingNews, error) {
+// ==== ACKNOWLEDGEMENT (item wajib) ====
+
+func (r *breakingNewsRepository) Acknowledge(id, userID int64) error {
+	_, err := r.db.Exec(`
+        INSERT INTO breaking_news_acks (breaking_news_id, user_id, item_version)
+        SELECT id, $2, version FROM breaking_news WHERE id = $1
+        ON CONFLICT DO NOTHING
+    `, id, userID)
+	return err
+}
+
+func (r *breakingNewsRepository) HasAcks(id int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM breaking_news_acks WHERE breaking_news_id = $1)
+    `, id).Scan(&ok)
+	return ok, err
+}
+
+func (r *breakingNewsRepository) ProductHasAcks(productID int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM breaking_news_acks a
+            JOIN breaking_news b ON b.id = a.breaking_news_id
+            WHERE b.product_id = $1
+        )
+    `, productID).Scan(&ok)
+	return ok, err
+}
+
+func (r *breakingNewsRepository) ListUnacknowledged(userID int64, aud models.Audience, page models.PageRequest) ([]*models.BreakingNews, int, error) {
+	where := activeBreakingNewsWhere + `
+          AND b.is_mandatory = TRUE
+          AND NOT EXISTS (
+              SELECT 1 FROM breaking_news_acks a
+              WHERE a.breaking_news_id = b.id AND a.user_id = $1 AND a.item_version = b.version
+          )
+          AND ` + breakingNewsVisibleSQL("$2")
+	return r.list(where, []any{userID, audienceParam(aud)}, "$2", severityRankSQL+" DESC, ", page)
+}
+
+func (r *breakingNewsRepository) AckSummary() ([]*models.BreakingNewsAckSummary, error) {
 	rows, err := r.db.Query(`
-        SELECT 
+        SELECT
             b.id,
             b.kind,
-            b.product_id,
             b.title,
+            b.severity,
             b.is_active,
             b.created_at,
-            b.updated_at,
-            p.slug,
-            p.title,
-            p.kind
+            (SELECT COUNT(*) FROM users u
+             WHERE u.role = 'agent' AND ` + breakingNewsAudienceSQL("b.id", "u.id") + `),
+            (SELECT COUNT(*) FROM breaking_news_acks a
+             JOIN users u ON u.id = a.user_id
+             WHERE a.breaking_news_id = b.id AND a.item_version = b.version AND u.role = 'agent'
+               AND ` + breakingNewsAudienceSQL("b.id", "u.id") + `)
         FROM breaking_news b
-        JOIN products p ON p.id = b.product_id
-        ORDER BY b.created_at DESC
+        WHERE b.is_mandatory = TRUE
+        ORDER BY b.created_at DESC, b.id DESC
     `)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var result []*models.BreakingNews
+	var list []*models.BreakingNewsAckSummary
 	for rows.Next() {
-		var (
-			b models.BreakingNews
-			p models.Product
-		)
+		var s models.BreakingNewsAckSummary
 		if err := rows.Scan(
-			&b.ID,
-			&b.Kind,
-			&b.ProductID,
-			&b.Title,
-			&b.IsActive,
-			&b.CreatedAt,
-			&b.UpdatedAt,
-			&p.Slug,
-			&p.Title,
-			&p.Kind,
+			&s.ID, &s.Kind, &s.Title, &s.Severity, &s.IsActive, &s.CreatedAt,
+			&s.Agents, &s.Acknowledged,
 		); err != nil {
 			return nil, err
 		}
-		b.ProductSlug = p.Slug
-		b.Product = &p
-		result = append(result, &b)
+		list = append(list, &s)
 	}
-	return result, nil
+	return list, nil
 }
 
-func (r *breakingNewsRepository) Delete(id int64) error {
-	_, err := r.db.Exec(`DELETE FROM breaking_news WHERE id = $1`, id)
-	return err
+func (r *breakingNewsRepository) AckUsers(id int64) ([]*models.BreakingNewsAck, error) {
+	rows, err := r.db.Query(`
+        SELECT u.id, u.username, u.name, a.acknowledged_at
+        FROM users u
+        LEFT JOIN breaking_news_acks a ON a.user_id = u.id AND a.breaking_news_id = $1
+             AND a.item_version = (SELECT version FROM breaking_news WHERE id = $1)
+        WHERE u.role = 'agent' AND `+breakingNewsAudienceSQL("$1", "u.id")+`
+        ORDER BY a.acknowledged_at IS NOT NULL, a.acknowledged_at, lower(u.username)
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.BreakingNewsAck
+	for rows.Next() {
+		var (
+			a  models.BreakingNewsAck
+			at sql.NullTime
+		)
+		if err := rows.Scan(&a.UserID, &a.Username, &a.Name, &at); err != nil {
+			return nil, err
+		}
+		if at.Valid {
+			t := at.Time
+			a.AcknowledgedAt = &t
+		}
+		list = append(list, &a)
+	}
+	return list, nil
 }
@@ -3,13 +3,64 @@ package repository
 import (
 	"cc-helper-backend/internal/models"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCategorySiblingExists  = errors.New("nama kategori sudah dipakai di level yang sama")
+	ErrCategoryCycle          = errors.New("kategori tidak bisa dipindah ke dirinya sendiri atau turunannya")
+	ErrCategoryKindMismatch   = errors.New("kategori harus sejenis (product/script)")
+	ErrCategoryOrderMismatch  = errors.New("urutan harus berisi semua sub-kategori di level itu, masing-masing sekali")
+	ErrCategoryNotEmpty       = errors.New("kategori masih berisi sub-kategori atau product")
+	ErrCategoryNotArchived    = errors.New("kategori tidak sedang diarsip")
+	ErrCategoryParentArchived = errors.New("induk kategori masih diarsip, pulihkan induknya dulu")
+	ErrCategoryTargetsLost    = errors.New("target tim kategori asal tidak tercakup oleh kategori tujuan, samakan target timnya dulu")
 )
 
 type CategoryRepository interface {
 	Create(c *models.Category) (int64, error)
+	// Update (nama, deskripsi, icon), Move & Merge jalan dalam 1 transaksi dan
+	// menjaga nama unik per sibling (termasuk root) + search_path product ikut diperbarui.
+	Update(c *models.Category) error
+	Move(id int64, parentID *int64) error
+	// Reorder mengisi sort_order = posisi di ids. ids harus persis semua anak
+	// langsung parentID (root kalau nil).
+	Reorder(kind models.ContentKind, parentID *int64, ids []int64) error
+	// Merge memindahkan semua product & sub-kategori source ke target lalu
+	// menghapus source. Ditolak (ErrCategoryTargetsLost) kalau target tim source
+	// tidak tercakup target, supaya konten terbatas tidak jadi terbuka.
+	Merge(sourceID, targetID int64) (*models.CategoryMergeResult, error)
+	// Delete hanya untuk kategori kosong (ErrCategoryNotEmpty kalau tidak).
 	Delete(id int64) error
+	// DeleteMoving memindah semua product subtree ke target lalu menghapus subtree
+	// (ErrCategoryTargetsLost seperti Merge).
+	DeleteMoving(id, targetID int64) (*models.CategoryDeleteResult, error)
+	// Archive menandai kategori, turunannya & product di dalamnya sebagai arsip.
+	Archive(id int64) (*models.CategoryDeleteResult, error)
+	// Restore memulihkan kategori arsip beserta turunan & product-nya
+	// (induknya harus aktif, nama & slug product tidak boleh bentrok).
+	Restore(id int64) (*models.CategoryRestoreResult, error)
+	// Impact = turunan & product yang kena kalau kategori dihapus
+	Impact(id int64) (*models.CategoryImpact, error)
+	// semua method baca di bawah mengabaikan kategori yang diarsip;
+	// yang menerima aud juga menyembunyikan kategori/product bertarget tim lain
 	GetByID(id int64) (*models.Category, error)
-	ListByParent(kind models.ContentKind, parentID *int64) ([]*models.Category, error)
+	// Visible = kategori ada, belum diarsip & tidak tertutup untuk aud
+	Visible(id int64, aud models.Audience) (bool, error)
+	ListByParent(kind models.ContentKind, parentID *int64, aud models.Audience) ([]*models.Category, error)
+	ListAll(aud models.Audience) ([]*models.Category, error)
+	DescendantIDs(id int64) ([]int64, error)
+	// AncestorIDs = [id, parent, grandparent, ...] (kosong kalau id tidak ada)
+	AncestorIDs(id int64) ([]int64, error)
+	// Paths = "root / ... / leaf" untuk banyak kategori sekaligus (1 query)
+	Paths(ids []int64) (map[int64]string, error)
+	// Counts = jumlah konten (subtree & langsung) + anak per kategori
+	Counts(ids []int64, aud models.Audience) (map[int64]*models.CategoryCounts, error)
+	Search(q string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.CategoryHit, error)
 }
 
 type categoryRepository struct {
@@ -21,61 +72,739 @@ func NewCategoryRepository(db *sql.DB) CategoryRepository {
 }
 
 func (r *categoryRepository) Create(c *models.Category) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return 0, err
+	}
+	if c.ParentID != nil {
+		parent, err := getCategoryTx(tx, *c.ParentID)
+		if err != nil {
+			return 0, err
+		}
+		if parent.Kind != c.Kind {
+			return 0, ErrCategoryKindMismatch
+		}
+	}
+	if err := checkSiblingName(tx, c.Kind, c.ParentID, c.Name, 0); err != nil {
+		return 0, err
+	}
+
+	// kategori baru ditaruh paling bawah di antara sibling-nya
 	var id int64
-	err := r.db.QueryRow(`
-		INSERT INTO categories (kind, name, parent_id)
-		VALUES ($1,$2,$3)
+	err = tx.QueryRow(`
+		INSERT INTO categories (kind, name, parent_id, description, icon, sort_order)
+		VALUES ($1,$2,$3,$4,$5,
+			(SELECT COALESCE(MAX(sort_order) + 1, 0) FROM categories
+			 WHERE kind = $1 AND parent_id IS NOT DISTINCT FROM $3))
 		RETURNING id
-	`, c.Kind, c.Name, c.ParentID).Scan(&id)
+	`, c.Kind, c.Name, c.ParentID, c.Description, c.Icon).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 	return id, nil
 }
 
-func (r *categoryRepository) Delete(id int64) error {
-	_, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
+func (r *categoryRepository) Update(c *models.Category) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	cur, err := getCategoryTx(tx, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkSiblingName(tx, cur.Kind, cur.ParentID, c.Name, c.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE categories
+		SET name = $1,
+			description = $2,
+			icon = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`, c.Name, c.Description, c.Icon, c.ID); err != nil {
+		return err
+	}
+	if cur.Name != c.Name {
+		if err := refreshSearchPaths(tx, c.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *categoryRepository) Move(id int64, parentID *int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	cur, err := getCategoryTx(tx, id)
+	if err != nil {
+		return err
+	}
+	if parentID != nil {
+		parent, err := getCategoryTx(tx, *parentID)
+		if err != nil {
+			return err
+		}
+		if parent.Kind != cur.Kind {
+			return ErrCategoryKindMismatch
+		}
+		inside, err := inSubtree(tx, id, *parentID)
+		if err != nil {
+			return err
+		}
+		if inside {
+			return ErrCategoryCycle
+		}
+	}
+	if err := checkSiblingName(tx, cur.Kind, parentID, cur.Name, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE categories
+		SET parent_id = $1,
+			sort_order = (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM categories
+			              WHERE kind = $3 AND parent_id IS NOT DISTINCT FROM $1 AND id <> $2),
+			updated_at = NOW()
+		WHERE id = $2
+	`, parentID, id, cur.Kind); err != nil {
+		return err
+	}
+	if err := refreshSearchPaths(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *categoryRepository) Reorder(kind models.ContentKind, parentID *int64, ids []int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	rows, err := tx.Query(`
+		SELECT id FROM categories
+		WHERE kind = $1 AND parent_id IS NOT DISTINCT FROM $2 AND archived_at IS NULL
+	`, kind, parentID)
+	if err != nil {
+		return err
+	}
+	current := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// daftar harus lengkap & tanpa duplikat, supaya urutan tidak setengah jadi
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !current[id] || seen[id] {
+			return ErrCategoryOrderMismatch
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(current) {
+		return ErrCategoryOrderMismatch
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE categories c
+		SET sort_order = o.pos - 1, updated_at = NOW()
+		FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, pos)
+		WHERE c.id = o.id AND c.sort_order <> o.pos - 1
+	`, pq.Array(ids)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *categoryRepository) Merge(sourceID, targetID int64) (*models.CategoryMergeResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return nil, err
+	}
+	src, err := getCategoryTx(tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := getCategoryTx(tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if src.Kind != dst.Kind {
+		return nil, ErrCategoryKindMismatch
+	}
+	// target = source atau turunannya -> sub-kategori akan jadi anak dirinya sendiri
+	inside, err := inSubtree(tx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if inside {
+		return nil, ErrCategoryCycle
+	}
+	if err := checkTargetsKept(tx, []int64{sourceID}, targetID); err != nil {
+		return nil, err
+	}
+
+	// sub-kategori source pindah ke target: namanya tidak boleh bentrok
+	var clash sql.NullString
+	err = tx.QueryRow(`
+		SELECT s.name
+		FROM categories s
+		JOIN categories t ON t.parent_id = $2 AND t.kind = s.kind AND lower(t.name) = lower(s.name)
+		                 AND t.archived_at IS NULL
+		WHERE s.parent_id = $1 AND s.archived_at IS NULL
+		LIMIT 1
+	`, sourceID, targetID).Scan(&clash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if clash.Valid {
+		return nil, fmt.Errorf("%w: %s", ErrCategorySiblingExists, clash.String)
+	}
+
+	res := &models.CategoryMergeResult{SourceID: sourceID, TargetID: targetID}
+	// sub-kategori (termasuk yang diarsip) & product source ikut pindah semua,
+	// kalau tidak DELETE source gagal/ikut menghapus arsip.
+	// sub-kategori source ditaruh di bawah sub-kategori target, urutan relatifnya tetap
+	out, err := tx.Exec(`
+		UPDATE categories
+		SET parent_id = $1,
+			sort_order = sort_order + (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM categories WHERE parent_id = $1),
+			updated_at = NOW()
+		WHERE parent_id = $2
+	`, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := out.RowsAffected()
+	res.MovedCategories = int(n)
+
+	out, err = tx.Exec(`UPDATE products SET category_id = $1 WHERE category_id = $2`, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	n, _ = out.RowsAffected()
+	res.MovedProducts = int(n)
+
+	// definisi atribut source ikut pindah, kecuali key yang sudah ada di target
+	if _, err := tx.Exec(`
+		DELETE FROM category_attributes s
+		USING category_attributes t
+		WHERE s.category_id = $1 AND t.category_id = $2 AND t.key = s.key
+	`, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE category_attributes SET category_id = $1 WHERE category_id = $2`, targetID, sourceID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM categories WHERE id = $1`, sourceID); err != nil {
+		return nil, err
+	}
+	if err := refreshSearchPaths(tx, targetID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// lockCategoryTree: semua perubahan struktur kategori diserialkan supaya
+// cek nama sibling & siklus tidak balapan dengan transaksi lain.
+func lockCategoryTree(tx *sql.Tx) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('categories'))`)
 	return err
 }
 
-func (r *categoryRepository) GetByID(id int64) (*models.Category, error) {
-	row := r.db.QueryRow(`
-		SELECT id, kind, name, parent_id, created_at, updated_at
+func getCategoryTx(tx *sql.Tx, id int64) (*models.Category, error) {
+	return scanCategory(tx.QueryRow(`
+		SELECT `+categoryColumns+`
 		FROM categories
-		WHERE id = $1
+		WHERE id = $1 AND archived_at IS NULL
+	`, id))
+}
+
+// checkSiblingName: index unik di DB tidak berlaku untuk root (parent NULL),
+// jadi aturan nama unik per level dicek di sini. excludeID = kategori itu sendiri.
+func checkSiblingName(tx *sql.Tx, kind models.ContentKind, parentID *int64, name string, excludeID int64) error {
+	var exists bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM categories
+			WHERE kind = $1 AND parent_id IS NOT DISTINCT FROM $2
+			  AND lower(name) = lower($3) AND id <> $4
+			  AND archived_at IS NULL
+		)
+	`, kind, parentID, name, excludeID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCategorySiblingExists
+	}
+	return nil
+}
+
+// inSubtree: apakah id ada di subtree rootID (termasuk rootID sendiri)
+func inSubtree(tx *sql.Tx, rootID, id int64) (bool, error) {
+	var found bool
+	err := tx.QueryRow(`
+		WITH RECURSIVE sub AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id
+		)
+		SELECT EXISTS (SELECT 1 FROM sub WHERE id = $2)
+	`, rootID, id).Scan(&found)
+	return found, err
+}
+
+// checkTargetsKept memastikan konten yang dipindah dari kategori fromIDs ke
+// targetID tidak kehilangan batasan tim. Tiap level bertarget di leluhur
+// fromIDs (termasuk dirinya) harus tercakup satu level bertarget di leluhur
+// targetID yang timnya subset level tsb. category_teams source ikut terhapus
+// (ON DELETE CASCADE), jadi tanpa cek ini konten terbatas terbuka untuk semua.
+func checkTargetsKept(tx *sql.Tx, fromIDs []int64, targetID int64) error {
+	var name string
+	err := tx.QueryRow(`
+		WITH RECURSIVE src AS (
+			SELECT id, parent_id FROM categories WHERE id = ANY($1)
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN src ON c.id = src.parent_id
+		), dst AS (
+			SELECT id, parent_id FROM categories WHERE id = $2
+			UNION ALL
+			SELECT c.id, c.parent_id FROM categories c JOIN dst ON c.id = dst.parent_id
+		)
+		SELECT c.name
+		FROM src
+		JOIN categories c ON c.id = src.id
+		WHERE EXISTS (SELECT 1 FROM category_teams WHERE category_id = src.id)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM dst
+		      WHERE EXISTS (SELECT 1 FROM category_teams WHERE category_id = dst.id)
+		        AND NOT EXISTS (
+		            SELECT 1 FROM category_teams dt
+		            WHERE dt.category_id = dst.id
+		              AND dt.team_id NOT IN (SELECT team_id FROM category_teams WHERE category_id = src.id)
+		        )
+		  )
+		LIMIT 1
+	`, pq.Array(fromIDs), targetID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrCategoryTargetsLost, name)
+}
+
+// refreshSearchPaths menghitung ulang products.search_path di subtree rootID
+func refreshSearchPaths(tx *sql.Tx, rootID int64) error {
+	_, err := tx.Exec(`
+		WITH RECURSIVE up AS (
+			SELECT parent_id, name, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.parent_id, c.name, up.depth + 1
+			FROM categories c JOIN up ON c.id = up.parent_id
+			WHERE up.depth < 64
+		), tree AS (
+			SELECT $1::bigint AS id, (SELECT string_agg(name, ' / ' ORDER BY depth DESC) FROM up) AS path
+			UNION ALL
+			SELECT c.id, tree.path || ' / ' || c.name
+			FROM categories c
+			JOIN tree ON c.parent_id = tree.id
+		)
+		UPDATE products p
+		SET search_path = tree.path
+		FROM tree
+		WHERE tree.id = p.category_id
+	`, rootID)
+	return err
+}
+
+// subtreeIDsTx = id kategori + semua turunannya, termasuk yang diarsip
+func subtreeIDsTx(tx *sql.Tx, id int64) ([]int64, error) {
+	rows, err := tx.Query(subtreeSQL("$1", true), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var cid int64
+		if err := rows.Scan(&cid); err != nil {
+			return nil, err
+		}
+		ids = append(ids, cid)
+	}
+	return ids, rows.Err()
+}
+
+func (r *categoryRepository) Delete(id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return err
+	}
+	if _, err := getCategoryTx(tx, id); err != nil {
+		return err
+	}
+	// arsip juga dihitung: DELETE akan ikut menghapus/menabrak FK-nya
+	var used bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)
+		    OR EXISTS (SELECT 1 FROM products WHERE category_id = $1)
+	`, id).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrCategoryNotEmpty
+	}
+	if _, err := tx.Exec(`DELETE FROM categories WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *categoryRepository) DeleteMoving(id, targetID int64) (*models.CategoryDeleteResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return nil, err
+	}
+	src, err := getCategoryTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := getCategoryTx(tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if src.Kind != dst.Kind {
+		return nil, ErrCategoryKindMismatch
+	}
+	ids, err := subtreeIDsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, sid := range ids {
+		if sid == targetID {
+			return nil, ErrCategoryCycle
+		}
+	}
+	if err := checkTargetsKept(tx, ids, targetID); err != nil {
+		return nil, err
+	}
+
+	res := &models.CategoryDeleteResult{Strategy: models.CategoryDeleteMove}
+	out, err := tx.Exec(`
+		UPDATE products SET category_id = $1, updated_at = NOW()
+		WHERE category_id = ANY($2)
+	`, targetID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ := out.RowsAffected()
+	res.MovedProducts = int(n)
+
+	// turunan ikut terhapus lewat ON DELETE CASCADE
+	if _, err := tx.Exec(`DELETE FROM categories WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	res.DeletedCategories = len(ids)
+	if err := refreshSearchPaths(tx, targetID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *categoryRepository) Archive(id int64) (*models.CategoryDeleteResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return nil, err
+	}
+	if _, err := getCategoryTx(tx, id); err != nil {
+		return nil, err
+	}
+	ids, err := subtreeIDsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.CategoryDeleteResult{Strategy: models.CategoryDeleteArchive}
+	out, err := tx.Exec(`
+		UPDATE categories SET archived_at = NOW(), updated_at = NOW()
+		WHERE id = ANY($1) AND archived_at IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ := out.RowsAffected()
+	res.ArchivedCategories = int(n)
+
+	out, err = tx.Exec(`
+		UPDATE products SET archived_at = NOW()
+		WHERE category_id = ANY($1) AND archived_at IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ = out.RowsAffected()
+	res.ArchivedProducts = int(n)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *categoryRepository) Restore(id int64) (*models.CategoryRestoreResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockCategoryTree(tx); err != nil {
+		return nil, err
+	}
+	var (
+		kind     models.ContentKind
+		name     string
+		parentID *int64
+		archived bool
+	)
+	err = tx.QueryRow(`
+		SELECT kind, name, parent_id, archived_at IS NOT NULL FROM categories WHERE id = $1
+	`, id).Scan(&kind, &name, &parentID, &archived)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		return nil, ErrCategoryNotArchived
+	}
+	if parentID != nil {
+		if _, err := getCategoryTx(tx, *parentID); err == sql.ErrNoRows {
+			return nil, ErrCategoryParentArchived
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := checkSiblingName(tx, kind, parentID, name, id); err != nil {
+		return nil, err
+	}
+	ids, err := subtreeIDsTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	var taken bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM products p
+			WHERE p.category_id = ANY($1) AND p.archived_at IS NOT NULL
+			  AND EXISTS (SELECT 1 FROM products q WHERE q.kind = p.kind AND q.slug = p.slug AND q.archived_at IS NULL)
+		)
+	`, pq.Array(ids)).Scan(&taken)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrProductSlugTaken
+	}
+
+	res := &models.CategoryRestoreResult{}
+	out, err := tx.Exec(`
+		UPDATE categories SET archived_at = NULL, updated_at = NOW()
+		WHERE id = ANY($1) AND archived_at IS NOT NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ := out.RowsAffected()
+	res.RestoredCategories = int(n)
+
+	out, err = tx.Exec(`
+		UPDATE products SET archived_at = NULL
+		WHERE category_id = ANY($1) AND archived_at IS NOT NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	n, _ = out.RowsAffected()
+	res.RestoredProducts = int(n)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *categoryRepository) Impact(id int64) (*models.CategoryImpact, error) {
+	cat, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	impact := &models.CategoryImpact{
+		Category:    cat,
+		Descendants: []*models.Category{},
+		Products:    []*models.Product{},
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+categoryColumns+`
+		FROM categories
+		WHERE id IN (`+subtreeSQL("$1", false)+`) AND id <> $1
+		ORDER BY sort_order, lower(name)
 	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		impact.Descendants = append(impact.Descendants, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	var c models.Category
-	var parent sql.NullInt64
-	if err := row.Scan(&c.ID, &c.Kind, &c.Name, &parent, &c.CreatedAt, &c.UpdatedAt); err != nil {
+	prows, err := r.db.Query(`
+		SELECT id, kind, slug, title, category_id
+		FROM products
+		WHERE category_id IN (`+subtreeSQL("$1", false)+`) AND archived_at IS NULL
+		ORDER BY lower(title), id
+	`, id)
+	if err != nil {
 		return nil, err
 	}
-	if parent.Valid {
-		p := parent.Int64
-		c.ParentID = &p
+	defer prows.Close()
+	for prows.Next() {
+		var p models.Product
+		if err := prows.Scan(&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID); err != nil {
+			return nil, err
+		}
+		impact.Products = append(impact.Products, &p)
 	}
-	return &c, nil
+	if err := prows.Err(); err != nil {
+		return nil, err
+	}
+
+	// arsip dihitung dengan aturan yang sama dengan Delete (strategi block)
+	err = r.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM categories
+			 WHERE id IN (`+subtreeSQL("$1", true)+`) AND id <> $1 AND archived_at IS NOT NULL),
+			(SELECT COUNT(*) FROM products
+			 WHERE category_id IN (`+subtreeSQL("$1", true)+`) AND archived_at IS NOT NULL)
+	`, id).Scan(&impact.ArchivedDescendants, &impact.ArchivedProducts)
+	if err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+func (r *categoryRepository) GetByID(id int64) (*models.Category, error) {
+	return scanCategory(r.db.QueryRow(`
+		SELECT `+categoryColumns+`
+		FROM categories
+		WHERE id = $1 AND archived_at IS NULL
+	`, id))
+}
+
+func (r *categoryRepository) Visible(id int64, aud models.Audience) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM categories
+			WHERE id = $1 AND archived_at IS NULL AND `+categoryVisibleSQL("categories.id", "$2")+`
+		)
+	`, id, audienceParam(aud)).Scan(&ok)
+	return ok, err
 }
 
-func (r *categoryRepository) ListByParent(kind models.ContentKind, parentID *int64) ([]*models.Category, error) {
+func (r *categoryRepository) ListByParent(kind models.ContentKind, parentID *int64, aud models.Audience) ([]*models.Category, error) {
 	var (
 		rows *sql.Rows
 		err  error
 	)
 	if parentID == nil {
 		rows, err = r.db.Query(`
-			SELECT id, kind, name, parent_id, created_at, updated_at
+			SELECT `+categoryColumns+`
 			FROM categories
-			WHERE kind = $1 AND parent_id IS NULL
-			ORDER BY lower(name)
-		`, kind)
+			WHERE kind = $1 AND parent_id IS NULL AND archived_at IS NULL
+			  AND `+categoryVisibleSQL("categories.id", "$2")+`
+			ORDER BY sort_order, lower(name)
+		`, kind, audienceParam(aud))
 	} else {
 		rows, err = r.db.Query(`
-			SELECT id, kind, name, parent_id, created_at, updated_at
+			SELECT `+categoryColumns+`
 			FROM categories
-			WHERE kind = $1 AND parent_id = $2
-			ORDER BY lower(name)
-		`, kind, *parentID)
+			WHERE kind = $1 AND parent_id = $2 AND archived_at IS NULL
+			  AND `+categoryVisibleSQL("categories.id", "$3")+`
+			ORDER BY sort_order, lower(name)
+		`, kind, *parentID, audienceParam(aud))
 	}
 	if err != nil {
 		return nil, err
@@ -84,16 +813,238 @@ func (r *categoryRepository) ListByParent(kind models.ContentKind, parentID *int
 
 	var list []*models.Category
 	for rows.Next() {
-		var c models.Category
-		var parent sql.NullInt64
-		if err := rows.Scan(&c.ID, &c.Kind, &c.Name, &parent, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := scanCategory(rows)
+		if err != nil {
 			return nil, err
 		}
-		if parent.Valid {
-			p := parent.Int64
-			c.ParentID = &p
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func (r *categoryRepository) ListAll(aud models.Audience) ([]*models.Category, error) {
+	rows, err := r.db.Query(`
+		SELECT `+categoryColumns+`
+		FROM categories
+		WHERE archived_at IS NULL AND `+categoryVisibleSQL("categories.id", "$1")+`
+		ORDER BY kind, sort_order, lower(name)
+	`, audienceParam(aud))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Category
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
 		}
-		list = append(list, &c)
+		list = append(list, c)
 	}
 	return list, nil
 }
+
+// DescendantIDs = id kategori ini + semua turunannya (subtree)
+func (r *categoryRepository) DescendantIDs(id int64) ([]int64, error) {
+	rows, err := r.db.Query(subtreeSQL("$1", false), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var cid int64
+		if err := rows.Scan(&cid); err != nil {
+			return nil, err
+		}
+		ids = append(ids, cid)
+	}
+	return ids, nil
+}
+
+func (r *categoryRepository) AncestorIDs(id int64) ([]int64, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id, up.depth + 1
+			FROM categories c JOIN up ON c.id = up.parent_id
+			WHERE up.depth < 64
+		)
+		SELECT id FROM up ORDER BY depth
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var cid int64
+		if err := rows.Scan(&cid); err != nil {
+			return nil, err
+		}
+		ids = append(ids, cid)
+	}
+	return ids, nil
+}
+
+func (r *categoryRepository) Paths(ids []int64) (map[int64]string, error) {
+	out := make(map[int64]string, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	// depth dibatasi supaya data rusak (siklus parent) tidak loop selamanya
+	rows, err := r.db.Query(`
+		WITH RECURSIVE up AS (
+			SELECT id AS leaf_id, parent_id, name, 0 AS depth
+			FROM categories WHERE id = ANY($1)
+			UNION ALL
+			SELECT up.leaf_id, c.parent_id, c.name, up.depth + 1
+			FROM categories c JOIN up ON c.id = up.parent_id
+			WHERE up.depth < 64
+		)
+		SELECT leaf_id, string_agg(name, ' / ' ORDER BY depth DESC)
+		FROM up
+		GROUP BY leaf_id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   int64
+			path string
+		)
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		out[id] = path
+	}
+	return out, nil
+}
+
+func (r *categoryRepository) Counts(ids []int64, aud models.Audience) (map[int64]*models.CategoryCounts, error) {
+	out := make(map[int64]*models.CategoryCounts, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := r.db.Query(`
+		WITH RECURSIVE tree AS (
+			SELECT id AS root_id, id FROM categories WHERE id = ANY($1)
+			UNION ALL
+			SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+			WHERE c.archived_at IS NULL
+		)
+		SELECT t.root_id,
+		       COUNT(p.id),
+		       COUNT(p.id) FILTER (WHERE p.category_id = t.root_id),
+		       (SELECT COUNT(*) FROM categories ch
+		        WHERE ch.parent_id = t.root_id AND ch.archived_at IS NULL
+		          AND `+categoryVisibleSQL("ch.id", "$2")+`)
+		FROM tree t
+		LEFT JOIN products p ON p.category_id = t.id AND p.archived_at IS NULL
+		     AND `+productVisibleSQL("p.id", "p.category_id", "$2")+`
+		GROUP BY t.root_id
+	`, pq.Array(ids), audienceParam(aud))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id int64
+			c  models.CategoryCounts
+		)
+		if err := rows.Scan(&id, &c.Contents, &c.Direct, &c.Children); err != nil {
+			return nil, err
+		}
+		out[id] = &c
+	}
+	return out, nil
+}
+
+// Search kategori by nama (substring atau mirip trigram).
+// categoryIDs != nil -> hanya kategori di dalam daftar itu.
+func (r *categoryRepository) Search(q string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.CategoryHit, error) {
+	rows, err := r.db.Query(`
+		SELECT `+categoryColumns+`,
+		       GREATEST(similarity(lower(name), lower($1)),
+		                CASE WHEN name ILIKE $5 THEN 1 ELSE 0 END) AS rank
+		FROM categories
+		WHERE (name ILIKE $5 OR name % $1)
+		  AND archived_at IS NULL
+		  AND ($2::bigint[] IS NULL OR id = ANY($2))
+		  AND `+categoryVisibleSQL("categories.id", "$4")+`
+		ORDER BY rank DESC, lower(name)
+		LIMIT $3
+	`, q, nullableIDs(categoryIDs), limit, audienceParam(aud), likeContains(q))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.CategoryHit
+	for rows.Next() {
+		var hit models.CategoryHit
+		c, err := scanCategory(rows, &hit.Rank)
+		if err != nil {
+			return nil, err
+		}
+		hit.Category = c
+		list = append(list, &hit)
+	}
+	return list, nil
+}
+
+const categoryColumns = `id, kind, name, parent_id, sort_order, description, icon, created_at, updated_at`
+
+// scanCategory membaca categoryColumns; extra = kolom tambahan sesudahnya.
+func scanCategory(row scanner, extra ...any) (*models.Category, error) {
+	var (
+		c      models.Category
+		parent sql.NullInt64
+		desc   sql.NullString
+		icon   sql.NullString
+	)
+	dest := append([]any{
+		&c.ID, &c.Kind, &c.Name, &parent, &c.SortOrder, &desc, &icon, &c.CreatedAt, &c.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if parent.Valid {
+		p := parent.Int64
+		c.ParentID = &p
+	}
+	if desc.Valid {
+		d := desc.String
+		c.Description = &d
+	}
+	if icon.Valid {
+		i := icon.String
+		c.Icon = &i
+	}
+	return &c, nil
+}
+
+// nullableIDs: nil slice -> NULL (tanpa filter), selain itu bigint[]
+func nullableIDs(ids []int64) any {
+	if ids == nil {
+		return nil
+	}
+	return pq.Array(ids)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains = pola (I)LIKE "mengandung s"; wildcard di input di-escape
+// supaya % dan _ dicari apa adanya
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+	"encoding/json"
+)
+
+type ContentChangeRepository interface {
+	Create(c *models.ContentChange) (int64, error)
+	GetByID(id int64) (*models.ContentChange, error)
+}
+
+type contentChangeRepository struct {
+	db *sql.DB
+}
+
+func NewContentChangeRepository(db *sql.DB) ContentChangeRepository {
+	return &contentChangeRepository{db: db}
+}
+
+func (r *contentChangeRepository) Create(c *models.ContentChange) (int64, error) {
+	before, _ := json.Marshal(nonNilBlocks(c.BlocksBefore))
+	after, _ := json.Marshal(nonNilBlocks(c.BlocksAfter))
+	summary, _ := json.Marshal(c.Summary)
+
+	var id int64
+	err := r.db.QueryRow(`
+		INSERT INTO content_changes (product_id, title_before, title_after, blocks_before, blocks_after, summary, created_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`, c.ProductID, c.TitleBefore, c.TitleAfter, before, after, summary, c.CreatedBy).Scan(&id)
+	return id, err
+}
+
+func (r *contentChangeRepository) GetByID(id int64) (*models.ContentChange, error) {
+	var (
+		c                      models.ContentChange
+		before, after, summary []byte
+		createdBy              sql.NullInt64
+	)
+	err := r.db.QueryRow(`
+		SELECT id, product_id, title_before, title_after, blocks_before, blocks_after, summary, created_by, created_at
+		FROM content_changes
+		WHERE id = $1
+	`, id).Scan(&c.ID, &c.ProductID, &c.TitleBefore, &c.TitleAfter, &before, &after, &summary, &createdBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal(before, &c.BlocksBefore)
+	_ = json.Unmarshal(after, &c.BlocksAfter)
+	_ = json.Unmarshal(summary, &c.Summary)
+	if createdBy.Valid {
+		uid := createdBy.Int64
+		c.CreatedBy = &uid
+	}
+	return &c, nil
+}
+
+func nonNilBlocks(blocks []models.ContentBlock) []models.ContentBlock {
+	if blocks == nil {
+		return []models.ContentBlock{}
+	}
+	return blocks
+}
@@ -4,17 +4,43 @@ import (
 	"cc-helper-backend/internal/models"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"html"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrProductSlugTaken        = errors.New("slug product sudah dipakai konten lain yang aktif")
+	ErrProductNotArchived      = errors.New("product tidak sedang diarsip")
+	ErrProductCategoryArchived = errors.New("kategori product masih diarsip, pulihkan kategorinya")
 )
 
 type ProductRepository interface {
 	GetByID(id int64) (*models.Product, error)
 	GetBySlug(kind models.ContentKind, slug string) (*models.Product, error)
-	List(kind models.ContentKind, q string, categoryID *int64, limit, offset int) ([]*models.Product, error)
+	List(f models.ProductFilter) ([]*models.Product, error)
+	Count(f models.ProductFilter) (int, error)
+	// AttributeStats = agregat atribut semua hasil filter (tanpa limit/offset)
+	AttributeStats(f models.ProductFilter) ([]*models.AttributeStat, error)
+	// Search menerima tsquery siap pakai (lihat SearchService.Expand)
+	// categoryIDs != nil membatasi hasil ke kategori tsb
+	Search(kind models.ContentKind, tsquery string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.SearchHit, error)
+	SearchFuzzy(kind models.ContentKind, q string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.SearchHit, error)
+	// Visible: product boleh dilihat aud (target product & kategorinya)
+	Visible(id int64, aud models.Audience) (bool, error)
+	// ListTitles = semua product & script tanpa blocks (untuk index autocomplete)
+	ListTitles() ([]*models.Product, error)
 
 	Create(p *models.Product) (int64, error)
+	// Update: sql.ErrNoRows kalau id tidak ada, sudah diarsip atau beda kind
 	Update(p *models.Product) error
 	Delete(id int64) error
+	// Restore memulihkan product arsip (kategorinya harus aktif & slug bebas)
+	Restore(id int64) error
 }
 
 type productRepository struct {
@@ -29,14 +55,16 @@ func (r *productRepository) scan(row scanner) (*models.Product, error) {
 	var (
 		p      models.Product
 		blocks []byte
+		attrs  []byte
 	)
 	if err := row.Scan(
 		&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID,
-		&blocks, &p.CreatedAt, &p.UpdatedAt,
+		&blocks, &attrs, &p.CreatedAt, &p.UpdatedAt,
 	); err != nil {
 		return nil, err
 	}
 	_ = json.Unmarshal(blocks, &p.Blocks)
+	_ = json.Unmarshal(attrs, &p.Attributes)
 	return &p, nil
 }
 
@@ -46,106 +74,470 @@ type scanner interface {
 
 func (r *productRepository) GetByID(id int64) (*models.Product, error) {
 	row := r.db.QueryRow(`
-		SELECT id, kind, slug, title, category_id, blocks, created_at, updated_at
+		SELECT id, kind, slug, title, category_id, blocks, attributes, created_at, updated_at
 		FROM products
-		WHERE id = $1
+		WHERE id = $1 AND archived_at IS NULL
 	`, id)
 	return r.scan(row)
 }
 
 func (r *productRepository) GetBySlug(kind models.ContentKind, slug string) (*models.Product, error) {
 	row := r.db.QueryRow(`
-		SELECT id, kind, slug, title, category_id, blocks, created_at, updated_at
+		SELECT id, kind, slug, title, category_id, blocks, attributes, created_at, updated_at
 		FROM products
-		WHERE kind = $1 AND slug = $2
+		WHERE kind = $1 AND slug = $2 AND archived_at IS NULL
 	`, kind, slug)
 	return r.scan(row)
 }
 
-func (r *productRepository) List(kind models.ContentKind, q string, categoryID *int64, limit, offset int) ([]*models.Product, error) {
-	base := `
-		SELECT id, kind, slug, title, category_id, blocks, created_at, updated_at
+// listWhere membangun "FROM ... WHERE ..." untuk List & Count.
+// qIdx = nomor parameter query full-text ("" kalau tanpa q).
+func listWhere(f models.ProductFilter) (where string, args []any, qIdx string) {
+	where = `
 		FROM products
-		WHERE kind = $1
+		WHERE kind = $1 AND archived_at IS NULL
 	`
-	args := []any{kind}
+	args = []any{f.Kind}
 	argIdx := 2
 
-	if q != "" {
-		base += " AND (title ILIKE $" + strconv.Itoa(argIdx) + " OR blocks::text ILIKE $" + strconv.Itoa(argIdx) + ")"
-		args = append(args, "%"+q+"%")
+	if !f.Audience.All {
+		where += " AND " + productVisibleSQL("products.id", "products.category_id", "$"+strconv.Itoa(argIdx))
+		args = append(args, audienceParam(f.Audience))
 		argIdx++
 	}
 
-	if categoryID != nil {
-		base += " AND category_id = $" + strconv.Itoa(argIdx)
-		args = append(args, *categoryID)
+	if f.Q != "" {
+		qIdx = strconv.Itoa(argIdx)
+		where += " AND search_vector @@ websearch_to_tsquery('indonesian', $" + qIdx + ")"
+		args = append(args, f.Q)
 		argIdx++
 	}
 
-	base += " ORDER BY lower(title)"
+	if f.CategoryID != nil {
+		if f.ExactCategory {
+			where += " AND category_id = $" + strconv.Itoa(argIdx)
+		} else {
+			where += " AND category_id IN (" + subtreeSQL("$"+strconv.Itoa(argIdx), false) + ")"
+		}
+		args = append(args, *f.CategoryID)
+		argIdx++
+	}
 
-	if limit > 0 {
-		base += " LIMIT $" + strconv.Itoa(argIdx)
-		args = append(args, limit)
+	for _, af := range f.Attributes {
+		keyIdx := strconv.Itoa(argIdx)
+		args = append(args, af.Key)
 		argIdx++
+
+		if len(af.Values) > 0 {
+			where += " AND attributes->>($" + keyIdx + "::text) = ANY($" + strconv.Itoa(argIdx) + ")"
+			args = append(args, pq.Array(af.Values))
+			argIdx++
+		}
+		if af.Min != nil {
+			where += " AND " + attrRangeExpr(keyIdx, *af.Min) + " >= $" + strconv.Itoa(argIdx)
+			args = append(args, *af.Min)
+			argIdx++
+		}
+		if af.Max != nil {
+			where += " AND " + attrRangeExpr(keyIdx, *af.Max) + " <= $" + strconv.Itoa(argIdx)
+			args = append(args, *af.Max)
+			argIdx++
+		}
+	}
+	return where, args, qIdx
+}
+
+// popularitySQL = jumlah klik dari hasil search 90 hari terakhir
+const popularitySQL = `(
+	SELECT COUNT(*) FROM search_queries sq
+	WHERE sq.clicked_type = products.kind AND sq.clicked_id = products.id
+	  AND sq.clicked_at > NOW() - INTERVAL '90 days'
+)`
+
+func (r *productRepository) List(f models.ProductFilter) ([]*models.Product, error) {
+	where, args, qIdx := listWhere(f)
+	query := `SELECT id, kind, slug, title, category_id, blocks, attributes, created_at, updated_at` + where
+
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	sort := f.Sort
+	if sort == "" || (sort == models.SortRelevance && qIdx == "") {
+		sort = models.SortTitle
+		if qIdx != "" {
+			sort = models.SortRelevance
+		}
 	}
-	if offset > 0 {
-		base += " OFFSET $" + strconv.Itoa(argIdx)
-		args = append(args, offset)
+	switch sort {
+	case models.SortRelevance:
+		query += " ORDER BY ts_rank_cd(search_vector, websearch_to_tsquery('indonesian', $" + qIdx + ")) DESC, lower(title), id"
+	case models.SortUpdatedAt:
+		query += " ORDER BY updated_at " + dir + ", id"
+	case models.SortCreatedAt:
+		query += " ORDER BY created_at " + dir + ", id"
+	case models.SortPopularity:
+		query += " ORDER BY " + popularitySQL + " " + dir + ", lower(title), id"
+	default:
+		query += " ORDER BY lower(title) " + dir + ", id"
+	}
+
+	argIdx := len(args) + 1
+	if f.Limit > 0 {
+		query += " LIMIT $" + strconv.Itoa(argIdx)
+		args = append(args, f.Limit)
 		argIdx++
 	}
+	if f.Offset > 0 {
+		query += " OFFSET $" + strconv.Itoa(argIdx)
+		args = append(args, f.Offset)
+	}
 
-	rows, err := r.db.Query(base, args...)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var list []*models.Product
+	for rows.Next() {
+		p, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// Count = jumlah total hasil List (tanpa limit/offset)
+func (r *productRepository) Count(f models.ProductFilter) (int, error) {
+	where, args, _ := listWhere(f)
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*)`+where, args...).Scan(&n)
+	return n, err
+}
+
+func (r *productRepository) AttributeStats(f models.ProductFilter) ([]*models.AttributeStat, error) {
+	where, args, _ := listWhere(f)
+	rows, err := r.db.Query(`
+		SELECT p.category_id, kv.key,
+		       CASE WHEN jsonb_typeof(kv.value) = 'string' THEN kv.value #>> '{}' END,
+		       COUNT(*),
+		       MIN((kv.value #>> '{}')::float8) FILTER (WHERE jsonb_typeof(kv.value) = 'number'),
+		       MAX((kv.value #>> '{}')::float8) FILTER (WHERE jsonb_typeof(kv.value) = 'number')
+		FROM (SELECT category_id, attributes`+where+`) p
+		CROSS JOIN LATERAL jsonb_each(p.attributes) kv
+		WHERE jsonb_typeof(kv.value) <> 'null'
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.AttributeStat
+	for rows.Next() {
+		var st models.AttributeStat
+		if err := rows.Scan(&st.CategoryID, &st.Key, &st.Value, &st.Count, &st.Min, &st.Max); err != nil {
+			return nil, err
+		}
+		list = append(list, &st)
+	}
+	return list, rows.Err()
+}
+
+// subtreeSQL = subquery id kategori root + semua turunannya.
+// withArchived = false -> cabang yang diarsip tidak ikut.
+func subtreeSQL(rootParam string, withArchived bool) string {
+	rootCond, childCond := " AND archived_at IS NULL", " WHERE c.archived_at IS NULL"
+	if withArchived {
+		rootCond, childCond = "", ""
+	}
+	return `
+		WITH RECURSIVE sub AS (
+			SELECT id FROM categories WHERE id = ` + rootParam + rootCond + `
+			UNION ALL
+			SELECT c.id FROM categories c JOIN sub ON c.parent_id = sub.id` + childCond + `
+		)
+		SELECT id FROM sub`
+}
+
+// attrRangeExpr: tanggal (YYYY-MM-DD) dibanding sebagai teks (urutan ISO aman),
+// selain itu dibanding numerik. Nilai non-angka jadi NULL supaya cast tidak error.
+func attrRangeExpr(keyIdx, sample string) string {
+	if isISODate(sample) {
+		return "(attributes->>($" + keyIdx + "::text))"
+	}
+	return "(CASE WHEN jsonb_typeof(attributes->($" + keyIdx + "::text)) = 'number' THEN (attributes->>($" + keyIdx + "::text))::numeric END)"
+}
+
+func isISODate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// highlight marker, di-escape dulu di Go baru diganti <mark>
+const (
+	hlStart = "[[hl]]"
+	hlStop  = "[[/hl]]"
+)
+
+func (r *productRepository) Search(kind models.ContentKind, tsquery string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.SearchHit, error) {
+	rows, err := r.db.Query(`
+		SELECT id, kind, slug, title, category_id, blocks, attributes, created_at, updated_at,
+		       ts_rank_cd(search_vector, query) AS rank,
+		       ts_headline('indonesian', search_body, query,
+		           'StartSel="`+hlStart+`", StopSel="`+hlStop+`", MaxFragments=2, MinWords=8, MaxWords=25, FragmentDelimiter=" … "'),
+		       ts_headline('indonesian', title, query,
+		           'StartSel="`+hlStart+`", StopSel="`+hlStop+`", HighlightAll=true')
+		FROM products, to_tsquery('indonesian', $2) query
+		WHERE kind = $1 AND search_vector @@ query AND archived_at IS NULL
+		  AND ($3::bigint[] IS NULL OR category_id = ANY($3))
+		  AND `+productVisibleSQL("products.id", "products.category_id", "$5")+`
+		ORDER BY rank DESC, lower(title)
+		LIMIT $4
+	`, kind, tsquery, nullableIDs(categoryIDs), limit, audienceParam(aud))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SearchHit
+	for rows.Next() {
+		var (
+			p       models.Product
+			blocks  []byte
+			attrs   []byte
+			hit     = models.SearchHit{Product: &p}
+			snippet string
+			title   string
+		)
+		if err := rows.Scan(
+			&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID,
+			&blocks, &attrs, &p.CreatedAt, &p.UpdatedAt,
+			&hit.Rank, &snippet, &title,
+		); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(blocks, &p.Blocks)
+		_ = json.Unmarshal(attrs, &p.Attributes)
+		hit.Snippet = highlight(snippet)
+		hit.TitleHighlight = highlight(title)
+		list = append(list, &hit)
+	}
+	return list, nil
+}
+
+// fuzzyWordThreshold = batas word_similarity untuk operator <% di SearchFuzzy
+const fuzzyWordThreshold = "0.5"
+
+// SearchFuzzy: fallback trigram (pg_trgm) di title untuk query yang salah ketik.
+// Kedua kondisi (% dan <%) memakai index trigram title; threshold <% di-set
+// per transaksi.
+func (r *productRepository) SearchFuzzy(kind models.ContentKind, q string, categoryIDs []int64, aud models.Audience, limit int) ([]*models.SearchHit, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SET LOCAL pg_trgm.word_similarity_threshold = ` + fuzzyWordThreshold); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+		SELECT id, kind, slug, title, category_id, blocks, attributes, created_at, updated_at,
+		       GREATEST(similarity(title, $2), word_similarity($2, title)) AS rank
+		FROM products
+		WHERE kind = $1 AND archived_at IS NULL
+		  AND (title % $2 OR $2 <% title)
+		  AND ($3::bigint[] IS NULL OR category_id = ANY($3))
+		  AND `+productVisibleSQL("products.id", "products.category_id", "$5")+`
+		ORDER BY rank DESC, lower(title)
+		LIMIT $4
+	`, kind, q, nullableIDs(categoryIDs), limit, audienceParam(aud))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SearchHit
 	for rows.Next() {
 		var (
 			p      models.Product
 			blocks []byte
+			attrs  []byte
+			hit    = models.SearchHit{Product: &p}
 		)
-		if err := rows.Scan(&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID, &blocks, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(
+			&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID,
+			&blocks, &attrs, &p.CreatedAt, &p.UpdatedAt,
+			&hit.Rank,
+		); err != nil {
 			return nil, err
 		}
 		_ = json.Unmarshal(blocks, &p.Blocks)
-		list = append(list, &p)
+		_ = json.Unmarshal(attrs, &p.Attributes)
+		hit.TitleHighlight = html.EscapeString(p.Title)
+		list = append(list, &hit)
 	}
 	return list, nil
 }
 
+func (r *productRepository) Visible(id int64, aud models.Audience) (bool, error) {
+	if aud.All {
+		return true, nil
+	}
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM products
+			WHERE id = $1 AND `+productVisibleSQL("products.id", "products.category_id", "$2")+`
+		)
+	`, id, audienceParam(aud)).Scan(&ok)
+	return ok, err
+}
+
+func highlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, hlStart, "<mark>")
+	return strings.ReplaceAll(s, hlStop, "</mark>")
+}
+
+// Create & Update ikut menulis search_body/search_path dan kosakata "did you
+// mean" di transaksi yang sama, supaya product tidak pernah tersimpan tanpa index.
 func (r *productRepository) Create(p *models.Product) (int64, error) {
 	blocks, _ := json.Marshal(p.Blocks)
+	attrs := marshalAttributes(p.Attributes)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	var id int64
-	err := r.db.QueryRow(`
-		INSERT INTO products (kind, slug, title, category_id, blocks)
-		VALUES ($1,$2,$3,$4,$5)
+	err = tx.QueryRow(`
+		INSERT INTO products (kind, slug, title, category_id, blocks, attributes, search_body, search_path)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
 		RETURNING id
-	`, p.Kind, p.Slug, p.Title, p.CategoryID, blocks).Scan(&id)
+	`, p.Kind, p.Slug, p.Title, p.CategoryID, blocks, attrs, p.SearchBody, p.SearchPath).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
+	if err := refreshVocabulary(tx, vocabProduct, id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 	return id, nil
 }
 
 func (r *productRepository) Update(p *models.Product) error {
 	blocks, _ := json.Marshal(p.Blocks)
-	_, err := r.db.Exec(`
+	attrs := marshalAttributes(p.Attributes)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	out, err := tx.Exec(`
 		UPDATE products
 		SET slug = $1,
 			title = $2,
 			category_id = $3,
 			blocks = $4,
+			attributes = $5,
+			search_body = $6,
+			search_path = $7,
 			updated_at = NOW()
-		WHERE id = $5 AND kind = $6
-	`, p.Slug, p.Title, p.CategoryID, blocks, p.ID, p.Kind)
-	return err
+		WHERE id = $8 AND kind = $9 AND archived_at IS NULL
+	`, p.Slug, p.Title, p.CategoryID, blocks, attrs, p.SearchBody, p.SearchPath, p.ID, p.Kind)
+	if err != nil {
+		return err
+	}
+	if n, _ := out.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if err := refreshVocabulary(tx, vocabProduct, p.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *productRepository) Delete(id int64) error {
 	_, err := r.db.Exec(`DELETE FROM products WHERE id = $1`, id)
 	return err
 }
+
+func (r *productRepository) Restore(id int64) error {
+	var (
+		archived, categoryActive, taken bool
+	)
+	err := r.db.QueryRow(`
+		SELECT p.archived_at IS NOT NULL,
+		       EXISTS (SELECT 1 FROM categories c WHERE c.id = p.category_id AND c.archived_at IS NULL),
+		       EXISTS (SELECT 1 FROM products q
+		               WHERE q.kind = p.kind AND q.slug = p.slug AND q.archived_at IS NULL AND q.id <> p.id)
+		FROM products p
+		WHERE p.id = $1
+	`, id).Scan(&archived, &categoryActive, &taken)
+	switch {
+	case err != nil:
+		return err
+	case !archived:
+		return ErrProductNotArchived
+	case !categoryActive:
+		return ErrProductCategoryArchived
+	case taken:
+		return ErrProductSlugTaken
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// search_path tetap diperbarui selama diarsip (refreshSearchPaths),
+	// kosakata judulnya yang perlu diisi lagi
+	if _, err := tx.Exec(`UPDATE products SET archived_at = NULL WHERE id = $1`, id); err != nil {
+		return err
+	}
+	if err := refreshVocabulary(tx, vocabProduct, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *productRepository) ListTitles() ([]*models.Product, error) {
+	rows, err := r.db.Query(`
+		SELECT id, kind, slug, title, category_id
+		FROM products
+		WHERE archived_at IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Product
+	for rows.Next() {
+		var p models.Product
+		if err := rows.Scan(&p.ID, &p.Kind, &p.Slug, &p.Title, &p.CategoryID); err != nil {
+			return nil, err
+		}
+		list = append(list, &p)
+	}
+	return list, nil
+}
+
+func marshalAttributes(attrs map[string]any) []byte {
+	if len(attrs) == 0 {
+		return []byte("{}")
+	}
+	b, _ := json.Marshal(attrs)
+	return b
+}
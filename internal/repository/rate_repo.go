@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+)
+
+type RateRepository interface {
+	GetTable(productID int64, key string) (*models.RateTable, error)
+	ListTables(productID int64) ([]*models.RateTable, error)
+	ListValues(tableID int64) ([]models.RateValue, error)
+	ValueAsOf(productID int64, key, date string) (*models.RateValue, error)
+	// SaveTable membuat/ubah tabel dan mengganti semua barisnya dalam 1 transaksi
+	SaveTable(t *models.RateTable, values []models.RateValue) (int64, error)
+	DeleteTable(productID int64, key string) error
+}
+
+type rateRepository struct {
+	db *sql.DB
+}
+
+func NewRateRepository(db *sql.DB) RateRepository {
+	return &rateRepository{db: db}
+}
+
+func (r *rateRepository) GetTable(productID int64, key string) (*models.RateTable, error) {
+	row := r.db.QueryRow(`
+		SELECT id, product_id, key, label, unit, created_at, updated_at
+		FROM product_rate_tables
+		WHERE product_id = $1 AND key = $2
+	`, productID, key)
+	return scanRateTable(row)
+}
+
+func (r *rateRepository) ListTables(productID int64) ([]*models.RateTable, error) {
+	rows, err := r.db.Query(`
+		SELECT id, product_id, key, label, unit, created_at, updated_at
+		FROM product_rate_tables
+		WHERE product_id = $1
+		ORDER BY lower(label)
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.RateTable
+	for rows.Next() {
+		t, err := scanRateTable(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+func scanRateTable(row scanner) (*models.RateTable, error) {
+	var (
+		t    models.RateTable
+		unit sql.NullString
+	)
+	if err := row.Scan(&t.ID, &t.ProductID, &t.Key, &t.Label, &unit, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if unit.Valid {
+		u := unit.String
+		t.Unit = &u
+	}
+	return &t, nil
+}
+
+// history: terbaru di atas
+func (r *rateRepository) ListValues(tableID int64) ([]models.RateValue, error) {
+	rows, err := r.db.Query(`
+		SELECT id, value, to_char(valid_from, 'YYYY-MM-DD'), to_char(valid_to, 'YYYY-MM-DD'), note
+		FROM product_rate_values
+		WHERE table_id = $1
+		ORDER BY valid_from DESC
+	`, tableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RateValue
+	for rows.Next() {
+		v, err := scanRateValue(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *v)
+	}
+	return list, nil
+}
+
+func (r *rateRepository) ValueAsOf(productID int64, key, date string) (*models.RateValue, error) {
+	row := r.db.QueryRow(`
+		SELECT v.id, v.value, to_char(v.valid_from, 'YYYY-MM-DD'), to_char(v.valid_to, 'YYYY-MM-DD'), v.note
+		FROM product_rate_values v
+		JOIN product_rate_tables t ON t.id = v.table_id
+		WHERE t.product_id = $1 AND t.key = $2
+		  AND v.valid_from <= $3::date
+		  AND (v.valid_to IS NULL OR v.valid_to >= $3::date)
+		ORDER BY v.valid_from DESC
+		LIMIT 1
+	`, productID, key, date)
+	return scanRateValue(row)
+}
+
+func scanRateValue(row scanner) (*models.RateValue, error) {
+	var (
+		v       models.RateValue
+		validTo sql.NullString
+		note    sql.NullString
+	)
+	if err := row.Scan(&v.ID, &v.Value, &v.ValidFrom, &validTo, &note); err != nil {
+		return nil, err
+	}
+	if validTo.Valid {
+		s := validTo.String
+		v.ValidTo = &s
+	}
+	if note.Valid {
+		s := note.String
+		v.Note = &s
+	}
+	return &v, nil
+}
+
+func (r *rateRepository) SaveTable(t *models.RateTable, values []models.RateValue) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO product_rate_tables (product_id, key, label, unit)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (product_id, key)
+		DO UPDATE SET label = EXCLUDED.label, unit = EXCLUDED.unit, updated_at = NOW()
+		RETURNING id
+	`, t.ProductID, t.Key, t.Label, t.Unit).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_rate_values WHERE table_id = $1`, id); err != nil {
+		return 0, err
+	}
+	for _, v := range values {
+		if _, err := tx.Exec(`
+			INSERT INTO product_rate_values (table_id, value, valid_from, valid_to, note)
+			VALUES ($1,$2,$3,$4,$5)
+		`, id, v.Value, v.ValidFrom, v.ValidTo, v.Note); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *rateRepository) DeleteTable(productID int64, key string) error {
+	_, err := r.db.Exec(`DELETE FROM product_rate_tables WHERE product_id = $1 AND key = $2`, productID, key)
+	return err
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"cc-helper-backend/internal/models"
 	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
 )
 
 type S2NodeRepository interface {
@@ -10,7 +13,10 @@ type S2NodeRepository interface {
 	Update(n *models.S2Node) error
 	Delete(id int64) error
 	GetByID(id int64) (*models.S2Node, error)
-	ListByParent(main models.S2MainType, parentID *int64) ([]*models.S2Node, error)
+	// ListByParent: link ke product yang tertutup untuk aud dikosongkan
+	ListByParent(main models.S2MainType, parentID *int64, aud models.Audience, page models.PageRequest) ([]*models.S2Node, int, error)
+	Search(q string, limit int) ([]*models.S2NodeHit, error)
+	Breadcrumbs(ids []int64) (map[int64][]string, error)
 }
 
 type s2NodeRepository struct {
@@ -29,8 +35,9 @@ func (r *s2NodeRepository) Create(n *models.S2Node) (int64, error) {
 			step_kind, title, body,
 			input_key, input_label, input_placeholder, input_required,
 			ui_mode,
-			link_kind, link_slug, sort_order
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+			link_kind, link_slug, sort_order,
+			calc_preset
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
 		RETURNING id
 	`,
 		n.MainType,
@@ -48,6 +55,7 @@ func (r *s2NodeRepository) Create(n *models.S2Node) (int64, error) {
 		n.LinkKind,
 		n.LinkSlug,
 		n.SortOrder,
+		marshalCalcPreset(n.CalcPreset),
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -73,8 +81,9 @@ func (r *s2NodeRepository) Update(n *models.S2Node) error {
 			link_kind = $13,
 			link_slug = $14,
 			sort_order = $15,
+			calc_preset = $16,
 			updated_at = NOW()
-		WHERE id = $16
+		WHERE id = $17
 	`,
 		n.MainType,
 		n.ParentID,
@@ -91,6 +100,7 @@ func (r *s2NodeRepository) Update(n *models.S2Node) error {
 		n.LinkKind,
 		n.LinkSlug,
 		n.SortOrder,
+		marshalCalcPreset(n.CalcPreset),
 		n.ID,
 	)
 	return err
@@ -108,6 +118,7 @@ func (r *s2NodeRepository) GetByID(id int64) (*models.S2Node, error) {
 		       input_key, input_label, input_placeholder, input_required,
 		       ui_mode,
 		       link_kind, link_slug, sort_order,
+		       calc_preset,
 		       created_at, updated_at
 		FROM s2_nodes
 		WHERE id = $1
@@ -131,6 +142,7 @@ func scanS2NodeRow(scanner interface {
 
 	var linkKind sql.NullString
 	var linkSlug sql.NullString
+	var calcPreset []byte
 
 	if err := scanner.Scan(
 		&n.ID,
@@ -154,6 +166,8 @@ func scanS2NodeRow(scanner interface {
 		&linkSlug,
 		&n.SortOrder,
 
+		&calcPreset,
+
 		&n.CreatedAt,
 		&n.UpdatedAt,
 	); err != nil {
@@ -211,43 +225,69 @@ func scanS2NodeRow(scanner interface {
 		n.LinkKind = &k
 	}
 
+	if len(calcPreset) > 0 {
+		var preset models.LoanCalcPreset
+		if err := json.Unmarshal(calcPreset, &preset); err == nil {
+			n.CalcPreset = &preset
+		}
+	}
+
 	return &n, nil
 }
 
-func (r *s2NodeRepository) ListByParent(main models.S2MainType, parentID *int64) ([]*models.S2Node, error) {
-	var (
-		rows *sql.Rows
-		err  error
-	)
+func marshalCalcPreset(p *models.LoanCalcPreset) any {
+	if p == nil {
+		return nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil
+	}
+	return b
+}
 
-	if parentID == nil {
-		rows, err = r.db.Query(`
-			SELECT id, main_type, parent_id, node_type, label,
-			       step_kind, title, body,
-			       input_key, input_label, input_placeholder, input_required,
-			       ui_mode,
-			       link_kind, link_slug, sort_order,
-			       created_at, updated_at
-			FROM s2_nodes
-			WHERE main_type = $1 AND parent_id IS NULL
-			ORDER BY sort_order, label
-		`, main)
-	} else {
-		rows, err = r.db.Query(`
-			SELECT id, main_type, parent_id, node_type, label,
-			       step_kind, title, body,
-			       input_key, input_label, input_placeholder, input_required,
-			       ui_mode,
-			       link_kind, link_slug, sort_order,
-			       created_at, updated_at
-			FROM s2_nodes
-			WHERE main_type = $1 AND parent_id = $2
-			ORDER BY sort_order, label
-		`, main, *parentID)
+// s2LinkHiddenSQL: node link ke product/script yang ada tapi tertutup untuk param
+func s2LinkHiddenSQL(param string) string {
+	return `EXISTS (
+		SELECT 1 FROM products lp
+		WHERE lp.kind = s2_nodes.link_kind::text AND lp.slug = s2_nodes.link_slug
+		  AND lp.archived_at IS NULL
+		  AND NOT ` + productVisibleSQL("lp.id", "lp.category_id", param) + `)`
+}
+
+func (r *s2NodeRepository) ListByParent(main models.S2MainType, parentID *int64, aud models.Audience, page models.PageRequest) ([]*models.S2Node, int, error) {
+	// parent NULL = root
+	where := `WHERE main_type = $1 AND parent_id IS NOT DISTINCT FROM $2`
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM s2_nodes `+where, main, parentID).Scan(&total); err != nil {
+		return nil, 0, err
 	}
 
+	order := "sort_order " + page.OrderDir() + ", label"
+	switch page.Sort {
+	case models.SortLabel:
+		order = "lower(label) " + page.OrderDir()
+	case models.SortUpdatedAt:
+		order = "updated_at " + page.OrderDir()
+	}
+	rows, err := r.db.Query(`
+		SELECT id, main_type, parent_id, node_type, label,
+		       step_kind, title, body,
+		       input_key, input_label, input_placeholder, input_required,
+		       ui_mode,
+		       CASE WHEN `+s2LinkHiddenSQL("$5")+` THEN NULL ELSE link_kind END,
+		       CASE WHEN `+s2LinkHiddenSQL("$5")+` THEN NULL ELSE link_slug END,
+		       sort_order,
+		       calc_preset,
+		       created_at, updated_at
+		FROM s2_nodes
+		`+where+`
+		ORDER BY `+order+`, id
+		LIMIT $3 OFFSET $4
+	`, main, parentID, page.PageSize, page.Offset(), audienceParam(aud))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -255,9 +295,93 @@ func (r *s2NodeRepository) ListByParent(main models.S2MainType, parentID *int64)
 	for rows.Next() {
 		n, err := scanS2NodeRow(rows)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		list = append(list, n)
 	}
+	return list, total, nil
+}
+
+// Search node S2PASS di label/title/body (HTML dibuang dulu)
+func (r *s2NodeRepository) Search(q string, limit int) ([]*models.S2NodeHit, error) {
+	rows, err := r.db.Query(`
+		SELECT id, main_type, parent_id, node_type, label,
+		       step_kind, title, body,
+		       input_key, input_label, input_placeholder, input_required,
+		       ui_mode,
+		       link_kind, link_slug, sort_order,
+		       calc_preset,
+		       created_at, updated_at,
+		       CASE
+		           WHEN label ILIKE $1 THEN 3
+		           WHEN title ILIKE $1 THEN 2
+		           ELSE 1
+		       END AS rank
+		FROM s2_nodes
+		WHERE label ILIKE $1
+		   OR title ILIKE $1
+		   OR regexp_replace(coalesce(body, ''), '<[^>]*>', ' ', 'g') ILIKE $1
+		ORDER BY rank DESC, sort_order, label
+		LIMIT $2
+	`, likeContains(q), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.S2NodeHit
+	for rows.Next() {
+		var rank float64
+		n, err := scanS2NodeRow(rankScanner{rows, &rank})
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &models.S2NodeHit{S2Node: n, Rank: rank})
+	}
 	return list, nil
 }
+
+// rankScanner menambahkan kolom rank di akhir supaya scanS2NodeRow bisa dipakai ulang
+type rankScanner struct {
+	rows *sql.Rows
+	rank *float64
+}
+
+func (s rankScanner) Scan(dest ...any) error {
+	return s.rows.Scan(append(dest, s.rank)...)
+}
+
+// Breadcrumbs: id node -> label dari root sampai node itu, dalam 1 query
+func (r *s2NodeRepository) Breadcrumbs(ids []int64) (map[int64][]string, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE chain AS (
+			SELECT id AS node_id, id, parent_id, label, 0 AS depth
+			FROM s2_nodes
+			WHERE id = ANY($1)
+			UNION ALL
+			SELECT c.node_id, p.id, p.parent_id, p.label, c.depth + 1
+			FROM chain c
+			JOIN s2_nodes p ON p.id = c.parent_id
+		)
+		SELECT node_id, label
+		FROM chain
+		ORDER BY node_id, depth DESC
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[int64][]string, len(ids))
+	for rows.Next() {
+		var (
+			id    int64
+			label string
+		)
+		if err := rows.Scan(&id, &label); err != nil {
+			return nil, err
+		}
+		out[id] = append(out[id], label)
+	}
+	return out, nil
+}
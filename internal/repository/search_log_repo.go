@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+	"time"
+)
+
+type SearchLogRepository interface {
+	Create(l *models.SearchQueryLog) (int64, error)
+	// RecordClick hanya mengubah log milik user yang sama
+	RecordClick(id, userID int64, targetType string, targetID int64) error
+
+	TopQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error)
+	ZeroResultQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error)
+	NoClickQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error)
+	// Trend per hari (interval "day") atau per minggu ("week")
+	Trend(rg models.SearchReportRange, interval string) ([]*models.SearchTrendPoint, error)
+}
+
+type searchLogRepository struct {
+	db *sql.DB
+}
+
+func NewSearchLogRepository(db *sql.DB) SearchLogRepository {
+	return &searchLogRepository{db: db}
+}
+
+func (r *searchLogRepository) Create(l *models.SearchQueryLog) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`
+		INSERT INTO search_queries (user_id, query, normalized, source, result_count, fuzzy)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING id
+	`, l.UserID, l.Query, l.Normalized, l.Source, l.ResultCount, l.Fuzzy).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *searchLogRepository) RecordClick(id, userID int64, targetType string, targetID int64) error {
+	res, err := r.db.Exec(`
+		UPDATE search_queries
+		SET clicked_type = $3, clicked_id = $4, clicked_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, id, userID, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// agregat per query; $1/$2 = periode (To inklusif), $3 = limit
+const searchStatSelect = `
+	SELECT normalized,
+	       COUNT(*),
+	       COUNT(DISTINCT user_id),
+	       COUNT(*) FILTER (WHERE result_count = 0),
+	       COUNT(*) FILTER (WHERE clicked_at IS NOT NULL),
+	       AVG(result_count)::float8,
+	       MAX(created_at)
+	FROM search_queries
+	WHERE created_at >= $1::date AND created_at < $2::date + 1
+	GROUP BY normalized
+`
+
+func (r *searchLogRepository) TopQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error) {
+	return r.queryStats(searchStatSelect+`
+		ORDER BY COUNT(*) DESC, MAX(created_at) DESC
+		LIMIT $3
+	`, rg)
+}
+
+func (r *searchLogRepository) ZeroResultQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error) {
+	return r.queryStats(searchStatSelect+`
+		HAVING COUNT(*) FILTER (WHERE result_count = 0) > 0
+		ORDER BY COUNT(*) FILTER (WHERE result_count = 0) DESC, MAX(created_at) DESC
+		LIMIT $3
+	`, rg)
+}
+
+// ada hasil tapi tidak pernah diklik = hasil kemungkinan tidak relevan
+func (r *searchLogRepository) NoClickQueries(rg models.SearchReportRange) ([]*models.SearchQueryStat, error) {
+	return r.queryStats(searchStatSelect+`
+		HAVING COUNT(*) FILTER (WHERE result_count > 0) > 0
+		   AND COUNT(*) FILTER (WHERE clicked_at IS NOT NULL) = 0
+		ORDER BY COUNT(*) DESC, MAX(created_at) DESC
+		LIMIT $3
+	`, rg)
+}
+
+func (r *searchLogRepository) queryStats(query string, rg models.SearchReportRange) ([]*models.SearchQueryStat, error) {
+	rows, err := r.db.Query(query, rg.From.Format(time.DateOnly), rg.To.Format(time.DateOnly), rg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SearchQueryStat
+	for rows.Next() {
+		var s models.SearchQueryStat
+		if err := rows.Scan(&s.Query, &s.Searches, &s.Users, &s.ZeroResults, &s.Clicks, &s.AvgResults, &s.LastSearched); err != nil {
+			return nil, err
+		}
+		list = append(list, &s)
+	}
+	return list, nil
+}
+
+func (r *searchLogRepository) Trend(rg models.SearchReportRange, interval string) ([]*models.SearchTrendPoint, error) {
+	rows, err := r.db.Query(`
+		SELECT to_char(date_trunc($3, created_at), 'YYYY-MM-DD'),
+		       COUNT(*),
+		       COUNT(DISTINCT user_id),
+		       COUNT(*) FILTER (WHERE result_count = 0),
+		       COUNT(*) FILTER (WHERE clicked_at IS NOT NULL)
+		FROM search_queries
+		WHERE created_at >= $1::date AND created_at < $2::date + 1
+		GROUP BY 1
+		ORDER BY 1
+	`, rg.From.Format(time.DateOnly), rg.To.Format(time.DateOnly), interval)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SearchTrendPoint
+	for rows.Next() {
+		var p models.SearchTrendPoint
+		if err := rows.Scan(&p.Period, &p.Searches, &p.Users, &p.ZeroResults, &p.Clicks); err != nil {
+			return nil, err
+		}
+		list = append(list, &p)
+	}
+	return list, nil
+}
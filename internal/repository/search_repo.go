@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type SearchRepository interface {
+	ListGlossary() ([]*models.GlossaryTerm, error)
+	CreateGlossaryTerm(t *models.GlossaryTerm) (int64, error)
+	DeleteGlossaryTerm(id int64) error
+
+	ListSynonyms() ([]*models.Synonym, error)
+	CreateSynonym(syn *models.Synonym) (int64, error)
+	UpdateSynonym(syn *models.Synonym) error
+	DeleteSynonym(id int64) error
+
+	// ListPromotions: activeOnly=true -> hanya yang berlaku sekarang
+	ListPromotions(activeOnly bool) ([]*models.SearchPromotion, error)
+	CreatePromotion(p *models.SearchPromotion) (int64, error)
+	UpdatePromotion(p *models.SearchPromotion) error
+	DeletePromotion(id int64) error
+
+	// SuggestWord mencari kata paling mirip (trigram) dari tabel kosakata
+	// (judul product/script + glossary + kamus sinonim, lihat search_vocabulary).
+	// Kosong kalau tidak ada yang cukup mirip.
+	SuggestWord(word string) (string, error)
+}
+
+type searchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) ListGlossary() ([]*models.GlossaryTerm, error) {
+	rows, err := r.db.Query(`
+		SELECT id, term, description, created_at
+		FROM search_glossary
+		ORDER BY lower(term)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.GlossaryTerm
+	for rows.Next() {
+		var (
+			t    models.GlossaryTerm
+			desc sql.NullString
+		)
+		if err := rows.Scan(&t.ID, &t.Term, &desc, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		if desc.Valid {
+			d := desc.String
+			t.Description = &d
+		}
+		list = append(list, &t)
+	}
+	return list, nil
+}
+
+func (r *searchRepository) CreateGlossaryTerm(t *models.GlossaryTerm) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO search_glossary (term, description)
+		VALUES ($1,$2)
+		RETURNING id
+	`, t.Term, t.Description).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err := refreshVocabulary(tx, vocabGlossary, id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *searchRepository) DeleteGlossaryTerm(id int64) error {
+	return r.execWithVocabulary(vocabGlossary, id, `DELETE FROM search_glossary WHERE id = $1`, id)
+}
+
+// execWithVocabulary menjalankan perubahan satu sumber kosakata lalu
+// menyegarkan katanya dalam 1 transaksi
+func (r *searchRepository) execWithVocabulary(source string, id int64, query string, args ...any) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := refreshVocabulary(tx, source, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *searchRepository) SuggestWord(word string) (string, error) {
+	var suggestion string
+	// kata dari product yang sudah diarsip dilewati (diganti lagi saat restore)
+	err := r.db.QueryRow(`
+		SELECT v.word
+		FROM search_vocabulary v
+		WHERE v.word % lower($1)
+		  AND (v.source <> 'product' OR EXISTS (
+		      SELECT 1 FROM products p WHERE p.id = v.source_id AND p.archived_at IS NULL
+		  ))
+		GROUP BY v.word
+		ORDER BY similarity(v.word, lower($1)) DESC, length(v.word)
+		LIMIT 1
+	`, word).Scan(&suggestion)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return suggestion, err
+}
+
+func (r *searchRepository) ListSynonyms() ([]*models.Synonym, error) {
+	rows, err := r.db.Query(`
+		SELECT id, term, synonyms, bidirectional, created_at, updated_at
+		FROM search_synonyms
+		ORDER BY lower(term)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Synonym
+	for rows.Next() {
+		var syn models.Synonym
+		if err := rows.Scan(
+			&syn.ID, &syn.Term, pq.Array(&syn.Synonyms), &syn.Bidirectional,
+			&syn.CreatedAt, &syn.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, &syn)
+	}
+	return list, nil
+}
+
+func (r *searchRepository) CreateSynonym(syn *models.Synonym) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO search_synonyms (term, synonyms, bidirectional)
+		VALUES ($1,$2,$3)
+		RETURNING id
+	`, syn.Term, pq.Array(syn.Synonyms), syn.Bidirectional).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err := refreshVocabulary(tx, vocabSynonym, id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *searchRepository) UpdateSynonym(syn *models.Synonym) error {
+	return r.execWithVocabulary(vocabSynonym, syn.ID, `
+		UPDATE search_synonyms
+		SET term = $1,
+			synonyms = $2,
+			bidirectional = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`, syn.Term, pq.Array(syn.Synonyms), syn.Bidirectional, syn.ID)
+}
+
+func (r *searchRepository) DeleteSynonym(id int64) error {
+	return r.execWithVocabulary(vocabSynonym, id, `DELETE FROM search_synonyms WHERE id = $1`, id)
+}
+
+func (r *searchRepository) ListPromotions(activeOnly bool) ([]*models.SearchPromotion, error) {
+	rows, err := r.db.Query(`
+		SELECT sp.id, sp.pattern, sp.match_type, sp.product_id, sp.position, sp.note,
+		       sp.starts_at, sp.ends_at, sp.created_at, sp.updated_at,
+		       p.kind, p.slug, p.title
+		FROM search_promotions sp
+		JOIN products p ON p.id = sp.product_id
+		WHERE NOT $1
+		   OR (p.archived_at IS NULL
+		       AND (sp.starts_at IS NULL OR sp.starts_at <= NOW())
+		       AND (sp.ends_at IS NULL OR sp.ends_at > NOW()))
+		ORDER BY sp.pattern, sp.position, sp.id
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.SearchPromotion
+	for rows.Next() {
+		var (
+			p              models.SearchPromotion
+			note           sql.NullString
+			startsAt, ends sql.NullTime
+		)
+		if err := rows.Scan(
+			&p.ID, &p.Pattern, &p.MatchType, &p.ProductID, &p.Position, &note,
+			&startsAt, &ends, &p.CreatedAt, &p.UpdatedAt,
+			&p.ProductKind, &p.ProductSlug, &p.ProductTitle,
+		); err != nil {
+			return nil, err
+		}
+		if note.Valid {
+			n := note.String
+			p.Note = &n
+		}
+		if startsAt.Valid {
+			t := startsAt.Time
+			p.StartsAt = &t
+		}
+		if ends.Valid {
+			t := ends.Time
+			p.EndsAt = &t
+		}
+		list = append(list, &p)
+	}
+	return list, nil
+}
+
+func (r *searchRepository) CreatePromotion(p *models.SearchPromotion) (int64, error) {
+	var id int64
+	err := r.db.QueryRow(`
+		INSERT INTO search_promotions (pattern, match_type, product_id, position, note, starts_at, ends_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`, p.Pattern, p.MatchType, p.ProductID, p.Position, p.Note, p.StartsAt, p.EndsAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *searchRepository) UpdatePromotion(p *models.SearchPromotion) error {
+	_, err := r.db.Exec(`
+		UPDATE search_promotions
+		SET pattern = $1,
+			match_type = $2,
+			product_id = $3,
+			position = $4,
+			note = $5,
+			starts_at = $6,
+			ends_at = $7,
+			updated_at = NOW()
+		WHERE id = $8
+	`, p.Pattern, p.MatchType, p.ProductID, p.Position, p.Note, p.StartsAt, p.EndsAt, p.ID)
+	return err
+}
+
+func (r *searchRepository) DeletePromotion(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM search_promotions WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import "database/sql"
+
+// sumber kosakata (search_vocabulary.source)
+const (
+	vocabProduct  = "product"
+	vocabGlossary = "glossary"
+	vocabSynonym  = "synonym"
+)
+
+// vocabularyWordsSQL = kata-kata satu baris sumber ($1 = id)
+var vocabularyWordsSQL = map[string]string{
+	vocabProduct: `
+		SELECT DISTINCT w
+		FROM products, regexp_split_to_table(lower(title), '[^[:alnum:]]+') w
+		WHERE id = $1 AND length(w) >= 3 AND archived_at IS NULL
+	`,
+	vocabGlossary: `SELECT lower(term) FROM search_glossary WHERE id = $1`,
+	vocabSynonym: `
+		SELECT lower(term) FROM search_synonyms WHERE id = $1
+		UNION
+		SELECT lower(unnest(synonyms)) FROM search_synonyms WHERE id = $1
+	`,
+}
+
+// refreshVocabulary mengganti kata milik satu sumber dengan isi terbarunya,
+// di transaksi yang sama dengan perubahan sumbernya.
+// Sumber yang sudah dihapus otomatis tidak menyisakan kata.
+func refreshVocabulary(tx *sql.Tx, source string, id int64) error {
+	if _, err := tx.Exec(`
+		DELETE FROM search_vocabulary WHERE source = $1 AND source_id = $2
+	`, source, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO search_vocabulary (source, source_id, word)
+		SELECT $2::text, $1::bigint, w.word FROM (`+vocabularyWordsSQL[source]+`) w(word)
+		ON CONFLICT DO NOTHING
+	`, id, source)
+	return err
+}
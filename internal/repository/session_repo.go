@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"cc-helper-backend/internal/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenReused: refresh token yang sudah dirotasi dipakai lagi
+// (kemungkinan dicuri); sesinya sudah dicabut
+var ErrRefreshTokenReused = errors.New("refresh token sudah pernah dipakai")
+
+type SessionRepository interface {
+	Create(s *models.Session, refreshHash string) (int64, error)
+	// Rotate mengganti refresh token sesi aktif (oldHash -> newHash) dan
+	// memperpanjang masa berlakunya. sql.ErrNoRows kalau token tidak dikenal,
+	// kedaluwarsa atau sesinya dicabut; ErrRefreshTokenReused kalau oldHash
+	// adalah token generasi mana pun yang sudah dirotasi (sesi langsung dicabut).
+	Rotate(oldHash, newHash string, expiresAt time.Time) (*models.Session, error)
+	// IsActive = sesi ada, belum dicabut & belum kedaluwarsa
+	IsActive(id int64) (bool, error)
+	Revoke(id int64) error
+	// RevokeAll mencabut semua sesi aktif user, mengembalikan jumlahnya
+	RevokeAll(userID int64) (int64, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(s *models.Session, refreshHash string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.QueryRow(`
+		INSERT INTO auth_sessions (user_id, user_agent, ip, expires_at)
+		VALUES ($1,$2,$3,$4)
+		RETURNING id
+	`, s.UserID, s.UserAgent, s.IP, s.ExpiresAt).Scan(&id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO auth_refresh_tokens (token_hash, session_id) VALUES ($1, $2)
+	`, refreshHash, id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *sessionRepository) Rotate(oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		sessionID int64
+		rotatedAt sql.NullTime
+	)
+	err = tx.QueryRow(`
+		SELECT session_id, rotated_at FROM auth_refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, oldHash).Scan(&sessionID, &rotatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if rotatedAt.Valid {
+		if _, err := tx.Exec(`
+			UPDATE auth_sessions SET revoked_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL
+		`, sessionID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	var (
+		s      models.Session
+		ua, ip sql.NullString
+	)
+	err = tx.QueryRow(`
+		UPDATE auth_sessions
+		SET expires_at = $2, last_used_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, user_agent, ip, expires_at, created_at, last_used_at
+	`, sessionID, expiresAt).Scan(&s.ID, &s.UserID, &ua, &ip, &s.ExpiresAt, &s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE auth_refresh_tokens SET rotated_at = NOW() WHERE token_hash = $1`, oldHash); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO auth_refresh_tokens (token_hash, session_id) VALUES ($1, $2)
+	`, newHash, sessionID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.UserAgent, s.IP = ua.String, ip.String
+	return &s, nil
+}
+
+func (r *sessionRepository) IsActive(id int64) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM auth_sessions
+			WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		)
+	`, id).Scan(&ok)
+	return ok, err
+}
+
+func (r *sessionRepository) Revoke(id int64) error {
+	_, err := r.db.Exec(`UPDATE auth_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+func (r *sessionRepository) RevokeAll(userID int64) (int64, error) {
+	res, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"cc-helper-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var ErrTeamNameExists = errors.New("nama tim sudah dipakai")
+
+type TeamRepository interface {
+	List() ([]*models.Team, error)
+	GetByID(id int64) (*models.Team, error)
+	Create(t *models.Team) (int64, error)
+	Update(t *models.Team) error
+	Delete(id int64) error
+	// SetMembers mengganti seluruh anggota tim
+	SetMembers(teamID int64, userIDs []int64) error
+	// UserTeamIDs = tim tempat user jadi anggota
+	UserTeamIDs(userID int64) ([]int64, error)
+
+	// Targets / SetTargets: target tim satu konten (models.Target*)
+	Targets(target string, id int64) ([]int64, error)
+	SetTargets(target string, id int64, teamIDs []int64) error
+	// TargetedBy = konten yang masih ditarget ke tim
+	TargetedBy(teamID int64) ([]models.TeamTarget, error)
+	// Rules = semua target product & kategori (untuk index autocomplete)
+	Rules() (*models.AudienceRules, error)
+}
+
+type teamRepository struct {
+	db *sql.DB
+}
+
+func NewTeamRepository(db *sql.DB) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+// tabel link per jenis target: nama tabel, kolom id konten
+var targetTables = map[string][2]string{
+	models.TargetBreakingNews: {"breaking_news_teams", "breaking_news_id"},
+	models.TargetCategory:     {"category_teams", "category_id"},
+	models.TargetProduct:      {"product_teams", "product_id"},
+}
+
+const teamSelect = `
+	SELECT t.id, t.name, t.created_at, t.updated_at,
+	       COALESCE(array_agg(ut.user_id ORDER BY ut.user_id) FILTER (WHERE ut.user_id IS NOT NULL), '{}')
+	FROM teams t
+	LEFT JOIN user_teams ut ON ut.team_id = t.id
+`
+
+func scanTeam(row scanner) (*models.Team, error) {
+	var t models.Team
+	if err := row.Scan(&t.ID, &t.Name, &t.CreatedAt, &t.UpdatedAt, (*pq.Int64Array)(&t.MemberIDs)); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *teamRepository) List() ([]*models.Team, error) {
+	rows, err := r.db.Query(teamSelect + ` GROUP BY t.id ORDER BY lower(t.name)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*models.Team{}
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+func (r *teamRepository) GetByID(id int64) (*models.Team, error) {
+	return scanTeam(r.db.QueryRow(teamSelect+` WHERE t.id = $1 GROUP BY t.id`, id))
+}
+
+// checkTeamName: nama unik case-insensitive (index teams_unique_name
+// tetap jadi pengaman terakhir)
+func (r *teamRepository) checkTeamName(name string, exceptID int64) error {
+	var exists bool
+	if err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM teams WHERE lower(name) = lower($1) AND id <> $2)
+	`, name, exceptID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrTeamNameExists
+	}
+	return nil
+}
+
+func (r *teamRepository) Create(t *models.Team) (int64, error) {
+	if err := r.checkTeamName(t.Name, 0); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := r.db.QueryRow(`INSERT INTO teams (name) VALUES ($1) RETURNING id`, t.Name).Scan(&id)
+	return id, err
+}
+
+func (r *teamRepository) Update(t *models.Team) error {
+	if err := r.checkTeamName(t.Name, t.ID); err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`UPDATE teams SET name = $1, updated_at = NOW() WHERE id = $2`, t.Name, t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *teamRepository) Delete(id int64) error {
+	_, err := r.db.Exec(`DELETE FROM teams WHERE id = $1`, id)
+	return err
+}
+
+func (r *teamRepository) SetMembers(teamID int64, userIDs []int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`, teamID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`DELETE FROM user_teams WHERE team_id = $1`, teamID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO user_teams (user_id, team_id)
+		SELECT DISTINCT u, $1 FROM unnest($2::int[]) u
+	`, teamID, pq.Array(userIDs)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *teamRepository) UserTeamIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(array_agg(team_id ORDER BY team_id), '{}') FROM user_teams WHERE user_id = $1
+	`, userID).Scan((*pq.Int64Array)(&ids))
+	return ids, err
+}
+
+func (r *teamRepository) Targets(target string, id int64) ([]int64, error) {
+	t, ok := targetTables[target]
+	if !ok {
+		return nil, fmt.Errorf("unknown target type %q", target)
+	}
+	var ids []int64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(array_agg(team_id ORDER BY team_id), '{}') FROM `+t[0]+` WHERE `+t[1]+` = $1
+	`, id).Scan((*pq.Int64Array)(&ids))
+	return ids, err
+}
+
+func (r *teamRepository) SetTargets(target string, id int64, teamIDs []int64) error {
+	t, ok := targetTables[target]
+	if !ok {
+		return fmt.Errorf("unknown target type %q", target)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM `+t[0]+` WHERE `+t[1]+` = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO `+t[0]+` (`+t[1]+`, team_id)
+		SELECT DISTINCT $1::bigint, tid FROM unnest($2::bigint[]) tid
+	`, id, pq.Array(teamIDs)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *teamRepository) TargetedBy(teamID int64) ([]models.TeamTarget, error) {
+	rows, err := r.db.Query(`
+		SELECT $2::text, b.id, b.title
+		FROM breaking_news_teams x JOIN breaking_news b ON b.id = x.breaking_news_id
+		WHERE x.team_id = $1
+		UNION ALL
+		SELECT $3::text, c.id, c.name
+		FROM category_teams x JOIN categories c ON c.id = x.category_id
+		WHERE x.team_id = $1
+		UNION ALL
+		SELECT $4::text, p.id, p.title
+		FROM product_teams x JOIN products p ON p.id = x.product_id
+		WHERE x.team_id = $1
+		ORDER BY 1, 2
+	`, teamID, models.TargetBreakingNews, models.TargetCategory, models.TargetProduct)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.TeamTarget
+	for rows.Next() {
+		var t models.TeamTarget
+		if err := rows.Scan(&t.Type, &t.ID, &t.Label); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *teamRepository) Rules() (*models.AudienceRules, error) {
+	rules := &models.AudienceRules{
+		Products:   map[int64][]int64{},
+		Categories: map[int64][]int64{},
+	}
+	rows, err := r.db.Query(`
+		SELECT 'product', product_id, team_id FROM product_teams
+		UNION ALL
+		SELECT 'category', category_id, team_id FROM category_teams
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			kind       string
+			id, teamID int64
+		)
+		if err := rows.Scan(&kind, &id, &teamID); err != nil {
+			return nil, err
+		}
+		if kind == "product" {
+			rules.Products[id] = append(rules.Products[id], teamID)
+		} else {
+			rules.Categories[id] = append(rules.Categories[id], teamID)
+		}
+	}
+	return rules, nil
+}
@@ -9,7 +9,7 @@ import (
 type UserRepository interface {
 	GetByUsername(username string) (*models.User, error)
 	GetByID(id int64) (*models.User, error)
-	List() ([]*models.User, error)
+	List(page models.PageRequest) ([]*models.User, int, error)
 	Create(u *models.User) (int64, error)
 	Update(u *models.User) error
 	Delete(id int64) error
@@ -48,13 +48,29 @@ func (r *userRepository) GetByID(id int64) (*models.User, error) {
 	return u, nil
 }
 
-func (r *userRepository) List() ([]*models.User, error) {
+func (r *userRepository) List(page models.PageRequest) ([]*models.User, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "id"
+	switch page.Sort {
+	case models.SortUsername:
+		order = "lower(username)"
+	case models.SortName:
+		order = "lower(name)"
+	case models.SortCreatedAt:
+		order = "created_at"
+	}
 	rows, err := r.db.Query(`
         SELECT id, username, name, role, created_at
-        FROM users ORDER BY id
-    `)
+        FROM users
+        ORDER BY `+order+` `+page.OrderDir()+`, id
+        LIMIT $1 OFFSET $2
+    `, page.PageSize, page.Offset())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -62,11 +78,11 @@ func (r *userRepository) List() ([]*models.User, error) {
 	for rows.Next() {
 		u := &models.User{}
 		if err := rows.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.CreatedAt); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		result = append(result, u)
 	}
-	return result, nil
+	return result, total, nil
 }
 
 func (r *userRepository) Create(u *models.User) (int64, error) {
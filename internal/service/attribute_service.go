@@ -0,0 +1,323 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AttributeService struct {
+	repo         repository.AttributeRepository
+	categoryRepo repository.CategoryRepository
+}
+
+func NewAttributeService(r repository.AttributeRepository, categoryRepo repository.CategoryRepository) *AttributeService {
+	return &AttributeService{repo: r, categoryRepo: categoryRepo}
+}
+
+var attrKeyRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func validAttributeType(t models.AttributeType) bool {
+	switch t {
+	case models.AttributeNumber, models.AttributePercentage, models.AttributeCurrency,
+		models.AttributeEnum, models.AttributeDate:
+		return true
+	}
+	return false
+}
+
+func (s *AttributeService) validateDefinition(a *models.CategoryAttribute) error {
+	verr := &ValidationError{}
+	a.Key = strings.TrimSpace(a.Key)
+	a.Label = strings.TrimSpace(a.Label)
+	if !attrKeyRegex.MatchString(a.Key) {
+		verr.add("key", "must be lowercase letters, digits or underscore, starting with a letter")
+	}
+	if a.Label == "" {
+		verr.add("label", "is required")
+	}
+	if !validAttributeType(a.Type) {
+		verr.add("type", "must be one of: number, percentage, currency, enum, date")
+	}
+	if a.Type == models.AttributeEnum && len(a.Options) == 0 {
+		verr.add("options", "is required for enum attribute")
+	}
+	if a.Type != models.AttributeEnum {
+		a.Options = nil
+	}
+	return verr.orNil()
+}
+
+func (s *AttributeService) Create(a *models.CategoryAttribute) (int64, error) {
+	if _, err := s.categoryRepo.GetByID(a.CategoryID); err != nil {
+		return 0, fmt.Errorf("kategori tidak ditemukan")
+	}
+	if err := s.validateDefinition(a); err != nil {
+		return 0, err
+	}
+	return s.repo.Create(a)
+}
+
+// Update tidak mengubah key & kategori (nilai di product sudah pakai key tsb)
+func (s *AttributeService) Update(a *models.CategoryAttribute) error {
+	cur, err := s.repo.GetByID(a.ID)
+	if err != nil {
+		return err
+	}
+	a.Key = cur.Key
+	a.CategoryID = cur.CategoryID
+	if err := s.validateDefinition(a); err != nil {
+		return err
+	}
+	return s.repo.Update(a)
+}
+
+func (s *AttributeService) Delete(id int64) error {
+	return s.repo.Delete(id)
+}
+
+// ancestorIDs: [categoryID, parent, grandparent, ...]
+func (s *AttributeService) ancestorIDs(categoryID int64) ([]int64, error) {
+	ids, err := s.categoryRepo.AncestorIDs(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return ids, nil
+}
+
+// SchemaFor = EffectiveSchema untuk agent: kategori bertarget tim lain
+// dianggap tidak ada
+func (s *AttributeService) SchemaFor(categoryID int64, aud models.Audience) ([]*models.CategoryAttribute, error) {
+	ok, err := s.categoryRepo.Visible(categoryID, aud)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return s.EffectiveSchema(categoryID)
+}
+
+// EffectiveSchema = atribut milik kategori + semua leluhurnya.
+// Kalau key sama, definisi di kategori yang lebih dalam yang dipakai.
+func (s *AttributeService) EffectiveSchema(categoryID int64) ([]*models.CategoryAttribute, error) {
+	ids, err := s.ancestorIDs(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repo.ListByCategories(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := make(map[int64]int, len(ids))
+	for i, id := range ids {
+		depth[id] = i
+	}
+	byKey := map[string]*models.CategoryAttribute{}
+	for _, a := range all {
+		if cur, ok := byKey[a.Key]; !ok || depth[a.CategoryID] < depth[cur.CategoryID] {
+			byKey[a.Key] = a
+		}
+	}
+
+	// tetap urut seperti hasil query (sort_order, label)
+	var out []*models.CategoryAttribute
+	for _, a := range all {
+		if byKey[a.Key] == a {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// ValidateValues mengecek nilai atribut product terhadap schema kategorinya
+// dan mengembalikan nilai yang sudah dinormalisasi.
+func (s *AttributeService) ValidateValues(categoryID int64, values map[string]any) (map[string]any, error) {
+	if len(values) == 0 {
+		return map[string]any{}, nil
+	}
+	schema, err := s.EffectiveSchema(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]*models.CategoryAttribute, len(schema))
+	for _, a := range schema {
+		defs[a.Key] = a
+	}
+
+	verr := &ValidationError{}
+	out := make(map[string]any, len(values))
+	for key, v := range values {
+		field := "attributes." + key
+		def, ok := defs[key]
+		if !ok {
+			verr.add(field, "is not defined for this category")
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		nv, msg := normalizeAttributeValue(def, v)
+		if msg != "" {
+			verr.add(field, "%s", msg)
+			continue
+		}
+		out[key] = nv
+	}
+	if err := verr.orNil(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func normalizeAttributeValue(def *models.CategoryAttribute, v any) (any, string) {
+	switch def.Type {
+	case models.AttributeNumber, models.AttributePercentage, models.AttributeCurrency:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if def.Type == models.AttributePercentage && (f < 0 || f > 100) {
+			return nil, "must be between 0 and 100"
+		}
+		if def.Type == models.AttributeCurrency && f < 0 {
+			return nil, "must not be negative"
+		}
+		return f, ""
+	case models.AttributeEnum:
+		str, ok := v.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		for _, o := range def.Options {
+			if o == str {
+				return str, ""
+			}
+		}
+		return nil, "must be one of: " + strings.Join(def.Options, ", ")
+	case models.AttributeDate:
+		str, ok := v.(string)
+		if !ok {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		return str, ""
+	}
+	return nil, "has unknown type"
+}
+
+// ValidateFilters memastikan nilai min/max bisa dibandingkan (angka atau tanggal)
+func (s *AttributeService) ValidateFilters(filters []models.AttributeFilter) error {
+	verr := &ValidationError{}
+	for _, f := range filters {
+		if !attrKeyRegex.MatchString(f.Key) {
+			verr.add("attr."+f.Key, "invalid attribute key")
+			continue
+		}
+		for suffix, v := range map[string]*string{"min": f.Min, "max": f.Max} {
+			if v == nil {
+				continue
+			}
+			if _, err := strconv.ParseFloat(*v, 64); err == nil {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", *v); err == nil {
+				continue
+			}
+			verr.add("attr."+f.Key+"."+suffix, "must be a number or date (YYYY-MM-DD)")
+		}
+	}
+	return verr.orNil()
+}
+
+// BuildFacets menyusun facet dari agregat atribut (lihat ProductRepository.AttributeStats):
+// jumlah per nilai untuk enum, min/max untuk angka & tanggal. Schema diambil
+// dari kategori tiap baris; urutan facet mengikuti schema kategori pertama.
+func (s *AttributeService) BuildFacets(stats []*models.AttributeStat) ([]*models.Facet, error) {
+	schemaCache := map[int64]map[string]*models.CategoryAttribute{}
+	facets := map[string]*models.Facet{}
+	enumCounts := map[string]map[string]int{}
+	var order []string
+
+	for _, st := range stats {
+		defs, ok := schemaCache[st.CategoryID]
+		if !ok {
+			schema, err := s.EffectiveSchema(st.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			defs = map[string]*models.CategoryAttribute{}
+			for _, def := range schema {
+				if !def.IsFilterable {
+					continue
+				}
+				defs[def.Key] = def
+				if _, ok := facets[def.Key]; !ok {
+					facets[def.Key] = &models.Facet{Key: def.Key, Label: def.Label, Type: def.Type, Unit: def.Unit}
+					enumCounts[def.Key] = map[string]int{}
+					order = append(order, def.Key)
+				}
+			}
+			schemaCache[st.CategoryID] = defs
+		}
+
+		def, ok := defs[st.Key]
+		if !ok {
+			continue
+		}
+		f := facets[st.Key]
+		f.Count += st.Count
+
+		switch def.Type {
+		case models.AttributeEnum:
+			if st.Value != nil {
+				enumCounts[def.Key][*st.Value] += st.Count
+			}
+		case models.AttributeDate:
+			if st.Value != nil {
+				str := *st.Value
+				if f.Min == nil || str < f.Min.(string) {
+					f.Min = str
+				}
+				if f.Max == nil || str > f.Max.(string) {
+					f.Max = str
+				}
+			}
+		default:
+			if st.Min != nil && (f.Min == nil || *st.Min < f.Min.(float64)) {
+				f.Min = *st.Min
+			}
+			if st.Max != nil && (f.Max == nil || *st.Max > f.Max.(float64)) {
+				f.Max = *st.Max
+			}
+		}
+	}
+
+	out := make([]*models.Facet, 0, len(order))
+	for _, key := range order {
+		f := facets[key]
+		for val, cnt := range enumCounts[key] {
+			f.Values = append(f.Values, models.FacetValue{Value: val, Count: cnt})
+		}
+		sort.Slice(f.Values, func(i, j int) bool {
+			if f.Values[i].Count != f.Values[j].Count {
+				return f.Values[i].Count > f.Values[j].Count
+			}
+			return f.Values[i].Value < f.Values[j].Value
+		})
+		out = append(out, f)
+	}
+	return out, nil
+}
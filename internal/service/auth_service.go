@@ -3,35 +3,87 @@ package service
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	accessTokenTTL = 15 * time.Minute
+	// refresh token berlaku 7 hari sejak terakhir dipakai (diperpanjang tiap rotasi)
+	refreshTokenTTL = 7 * 24 * time.Hour
+	// tiket stream /events: sekali pakai & pendek karena lewat query string
+	// (URL ikut tercatat di log)
+	streamTicketTTL = 30 * time.Second
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionRevoked      = errors.New("session revoked")
+)
+
 type AuthService struct {
 	users     repository.UserRepository
+	sessions  repository.SessionRepository
+	events    *EventHub
 	jwtSecret []byte
+
+	ticketMu sync.Mutex
+	tickets  map[string]streamTicket // key = hashToken(tiket)
 }
 
+type streamTicket struct {
+	claims    Claims
+	expiresAt time.Time
+}
+
+// AuthResult = balasan login & refresh. Token = access token (Bearer).
 type AuthResult struct {
-	Token string       `json:"token"`
-	User  *models.User `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"` // detik sampai Token kedaluwarsa
+	User         *models.User `json:"user"`
 }
 
 type Claims struct {
-	UserID   int64       `json:"user_id"`
-	Username string      `json:"username"`
-	Role     models.Role `json:"role"`
+	UserID    int64       `json:"user_id"`
+	Username  string      `json:"username"`
+	Role      models.Role `json:"role"`
+	SessionID int64       `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(userRepo repository.UserRepository, secret string) *AuthService {
-	return &AuthService{users: userRepo, jwtSecret: []byte(secret)}
+// StreamTicket = balasan POST /events/ticket
+type StreamTicket struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// ClientInfo = info perangkat yang dicatat di sesi
+type ClientInfo struct {
+	UserAgent string
+	IP        string
 }
 
-func (s *AuthService) Login(username, password string) (*AuthResult, error) {
+func NewAuthService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, events *EventHub, secret string) *AuthService {
+	return &AuthService{
+		users:     userRepo,
+		sessions:  sessionRepo,
+		events:    events,
+		jwtSecret: []byte(secret),
+		tickets:   map[string]streamTicket{},
+	}
+}
+
+func (s *AuthService) Login(username, password string, client ClientInfo) (*AuthResult, error) {
 	u, err := s.users.GetByUsername(username)
 	if err != nil {
 		return nil, errors.New("invalid username or password")
@@ -40,13 +92,71 @@ func (s *AuthService) Login(username, password string) (*AuthResult, error) {
 		return nil, errors.New("invalid username or password")
 	}
 
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	sid, err := s.sessions.Create(&models.Session{
+		UserID:    u.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, hash)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(u, sid, refresh)
+}
+
+// Refresh menukar refresh token dengan access token + refresh token baru
+// (rotasi). Data user dibaca ulang supaya perubahan role langsung berlaku.
+func (s *AuthService) Refresh(refreshToken string) (*AuthResult, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.sessions.Rotate(hashToken(refreshToken), hash, time.Now().Add(refreshTokenTTL))
+	if err == sql.ErrNoRows || errors.Is(err, repository.ErrRefreshTokenReused) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.users.GetByID(sess.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issue(u, sess.ID, refresh)
+}
+
+// Logout mencabut sesi milik access token yang dipakai lalu menutup stream
+// /events sesi itu (tab lain yang memakai sesi yang sama ikut logout)
+func (s *AuthService) Logout(userID, sessionID int64) error {
+	if err := s.sessions.Revoke(sessionID); err != nil {
+		return err
+	}
+	s.events.PublishToSession(userID, sessionID, models.EventLogout, models.LogoutEvent{})
+	return nil
+}
+
+// RevokeAll mencabut semua sesi user (access token-nya langsung ditolak)
+func (s *AuthService) RevokeAll(userID int64) (int64, error) {
+	return s.sessions.RevokeAll(userID)
+}
+
+func (s *AuthService) issue(u *models.User, sessionID int64, refresh string) (*AuthResult, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID:   u.ID,
-		Username: u.Username,
-		Role:     u.Role,
+		UserID:    u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -58,13 +168,18 @@ func (s *AuthService) Login(username, password string) (*AuthResult, error) {
 
 	// jangan kirim password_hash
 	u.PasswordHash = ""
-	return &AuthResult{Token: tokenStr, User: u}, nil
+	return &AuthResult{
+		Token:        tokenStr,
+		RefreshToken: refresh,
+		ExpiresIn:    int(accessTokenTTL / time.Second),
+		User:         u,
+	}, nil
 }
 
 func (s *AuthService) ParseToken(tokenStr string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
 		return s.jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
@@ -74,3 +189,78 @@ func (s *AuthService) ParseToken(tokenStr string) (*Claims, error) {
 	}
 	return claims, nil
 }
+
+// Authenticate = ParseToken + cek sesi belum dicabut. Token lama tanpa sid
+// (sebelum ada sesi) ikut ditolak.
+func (s *AuthService) Authenticate(tokenStr string) (*Claims, error) {
+	claims, err := s.ParseToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == 0 {
+		return nil, ErrSessionRevoked
+	}
+	active, err := s.sessions.IsActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+// IssueStreamTicket membuat tiket sekali pakai untuk membuka /events
+// (EventSource tidak bisa mengirim header Authorization)
+func (s *AuthService) IssueStreamTicket(claims Claims) (*StreamTicket, error) {
+	ticket, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s.ticketMu.Lock()
+	defer s.ticketMu.Unlock()
+	for k, t := range s.tickets {
+		if now.After(t.expiresAt) {
+			delete(s.tickets, k)
+		}
+	}
+	s.tickets[hash] = streamTicket{claims: claims, expiresAt: now.Add(streamTicketTTL)}
+	return &StreamTicket{Ticket: ticket, ExpiresIn: int(streamTicketTTL / time.Second)}, nil
+}
+
+// RedeemStreamTicket menukar tiket (sekali pakai) dengan claims pemiliknya;
+// sesi yang sudah dicabut sejak tiket dibuat ikut ditolak
+func (s *AuthService) RedeemStreamTicket(ticket string) (*Claims, error) {
+	hash := hashToken(ticket)
+	s.ticketMu.Lock()
+	t, ok := s.tickets[hash]
+	delete(s.tickets, hash)
+	s.ticketMu.Unlock()
+	if !ok || time.Now().After(t.expiresAt) {
+		return nil, errors.New("invalid ticket")
+	}
+	active, err := s.sessions.IsActive(t.claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+	return &t.claims, nil
+}
+
+// newRefreshToken = token acak untuk client + hash yang disimpan di DB
+func newRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	autocompleteLimit    = 5
+	maxAutocompleteLimit = 10
+	maxRecentQueries     = 100 // per user
+)
+
+// Autocompleter = index in-memory untuk typeahead (dipanggil tiap ketikan).
+// Index judul & kategori dibangun ulang secara lazy setelah MarkDirty,
+// query terakhir dicatat per user dari SearchService (query satu agent bisa
+// berisi data nasabah, jadi tidak boleh muncul sebagai saran untuk user lain).
+type Autocompleter struct {
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	teamRepo     repository.TeamRepository
+
+	index   atomic.Pointer[acIndex]
+	dirty   atomic.Bool
+	buildMu sync.Mutex
+
+	recentMu sync.Mutex
+	recent   map[int64]map[string]*recentQuery // user id -> norm -> query
+}
+
+type acEntry struct {
+	norm  string // lowercase, kata dipisah spasi
+	words []string
+	item  models.AutocompleteItem
+	// targets = target tim konten & semua kategori leluhurnya;
+	// entry tampil kalau audience lolos semuanya
+	targets [][]int64
+}
+
+type acWord struct {
+	word  string
+	entry *acEntry
+}
+
+type acIndex struct {
+	titles     []acWord // urut by word, untuk binary search prefix
+	categories []acWord
+}
+
+type recentQuery struct {
+	text  string
+	norm  string
+	count int
+	last  time.Time
+}
+
+func NewAutocompleter(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, teamRepo repository.TeamRepository) *Autocompleter {
+	a := &Autocompleter{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		teamRepo:     teamRepo,
+		recent:       map[int64]map[string]*recentQuery{},
+	}
+	a.dirty.Store(true)
+	return a
+}
+
+// MarkDirty dipanggil setiap konten/kategori berubah. Aman untuk nil.
+func (a *Autocompleter) MarkDirty() {
+	if a == nil {
+		return
+	}
+	a.dirty.Store(true)
+}
+
+// RecordQuery mencatat query milik userID yang menghasilkan sesuatu. Aman untuk nil.
+func (a *Autocompleter) RecordQuery(userID int64, q string) {
+	if a == nil {
+		return
+	}
+	norm := normalizePhrase(q)
+	if norm == "" {
+		return
+	}
+
+	a.recentMu.Lock()
+	defer a.recentMu.Unlock()
+	recent := a.recent[userID]
+	if recent == nil {
+		recent = map[string]*recentQuery{}
+		a.recent[userID] = recent
+	}
+	if rq, ok := recent[norm]; ok {
+		rq.count++
+		rq.last = time.Now()
+		return
+	}
+	if len(recent) >= maxRecentQueries {
+		var oldest *recentQuery
+		for _, rq := range recent {
+			if oldest == nil || rq.last.Before(oldest.last) {
+				oldest = rq
+			}
+		}
+		delete(recent, oldest.norm)
+	}
+	recent[norm] = &recentQuery{text: strings.TrimSpace(q), norm: norm, count: 1, last: time.Now()}
+}
+
+// Suggest mencari judul, kategori & query terakhir userID yang cocok dengan
+// input parsial. Tiap kata input harus jadi awalan salah satu kata kandidat.
+// Judul & kategori bertarget tim lain tidak ikut (aud).
+func (a *Autocompleter) Suggest(q string, limit int, userID int64, aud models.Audience) (*models.AutocompleteResult, error) {
+	if limit <= 0 {
+		limit = autocompleteLimit
+	}
+	limit = min(limit, maxAutocompleteLimit)
+
+	res := &models.AutocompleteResult{
+		Titles:     []models.AutocompleteItem{},
+		Categories: []models.AutocompleteItem{},
+		Queries:    []models.AutocompleteItem{},
+	}
+	tokens := searchTokenRegex.FindAllString(strings.ToLower(q), -1)
+	if len(tokens) == 0 {
+		return res, nil
+	}
+
+	idx, err := a.current()
+	if err != nil {
+		return nil, err
+	}
+	norm := strings.Join(tokens, " ")
+	res.Titles = matchEntries(idx.titles, tokens, norm, aud, limit)
+	res.Categories = matchEntries(idx.categories, tokens, norm, aud, limit)
+	res.Queries = a.matchRecent(userID, norm, limit)
+	return res, nil
+}
+
+// current mengembalikan index terbaru; build ulang kalau dirty.
+// Kalau build gagal tapi index lama ada, index lama tetap dipakai.
+func (a *Autocompleter) current() (*acIndex, error) {
+	if idx := a.index.Load(); idx != nil && !a.dirty.Load() {
+		return idx, nil
+	}
+
+	a.buildMu.Lock()
+	defer a.buildMu.Unlock()
+	if idx := a.index.Load(); idx != nil && !a.dirty.Load() {
+		return idx, nil
+	}
+
+	// reset dulu: perubahan selama build akan menandai dirty lagi
+	a.dirty.Store(false)
+	idx, err := a.build()
+	if err != nil {
+		a.dirty.Store(true)
+		if old := a.index.Load(); old != nil {
+			return old, nil
+		}
+		return nil, err
+	}
+	a.index.Store(idx)
+	return idx, nil
+}
+
+func (a *Autocompleter) build() (*acIndex, error) {
+	products, err := a.productRepo.ListTitles()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := a.categoryRepo.ListAll(models.AudienceAll)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := a.teamRepo.Rules()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	path := func(id int64) string {
+		var names []string
+		// batas len(byID) menjaga dari siklus parent
+		for c := byID[id]; c != nil && len(names) < len(byID); {
+			names = append([]string{c.Name}, names...)
+			if c.ParentID == nil {
+				break
+			}
+			c = byID[*c.ParentID]
+		}
+		return strings.Join(names, " / ")
+	}
+	// target tim kategori id + leluhurnya (yang bertarget saja)
+	categoryTargets := func(id int64) [][]int64 {
+		var out [][]int64
+		seen := 0
+		for c := byID[id]; c != nil && seen < len(byID); seen++ {
+			if t := rules.Categories[c.ID]; len(t) > 0 {
+				out = append(out, t)
+			}
+			if c.ParentID == nil {
+				break
+			}
+			c = byID[*c.ParentID]
+		}
+		return out
+	}
+
+	idx := &acIndex{}
+	for _, p := range products {
+		targets := categoryTargets(p.CategoryID)
+		if t := rules.Products[p.ID]; len(t) > 0 {
+			targets = append(targets, t)
+		}
+		idx.titles = addEntry(idx.titles, p.Title, targets, models.AutocompleteItem{
+			Text: p.Title,
+			Target: &models.SearchTarget{
+				Type: string(p.Kind),
+				ID:   p.ID,
+				Slug: p.Slug,
+				Kind: p.Kind,
+				Path: path(p.CategoryID),
+			},
+		})
+	}
+	for _, c := range categories {
+		idx.categories = addEntry(idx.categories, c.Name, categoryTargets(c.ID), models.AutocompleteItem{
+			Text: c.Name,
+			Target: &models.SearchTarget{
+				Type: models.SearchTypeCategory,
+				ID:   c.ID,
+				Kind: c.Kind,
+				Path: path(c.ID),
+			},
+		})
+	}
+	sortWords(idx.titles)
+	sortWords(idx.categories)
+	return idx, nil
+}
+
+func addEntry(list []acWord, text string, targets [][]int64, item models.AutocompleteItem) []acWord {
+	words := searchTokenRegex.FindAllString(strings.ToLower(text), -1)
+	if len(words) == 0 {
+		return list
+	}
+	e := &acEntry{norm: strings.Join(words, " "), words: words, item: item, targets: targets}
+	seen := map[string]bool{}
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			list = append(list, acWord{word: w, entry: e})
+		}
+	}
+	return list
+}
+
+func sortWords(list []acWord) {
+	sort.Slice(list, func(i, j int) bool { return list[i].word < list[j].word })
+}
+
+// matchEntries: kandidat diambil dari kata terpanjang input (paling selektif),
+// lalu disaring supaya semua kata input cocok. Urutan: teks yang diawali
+// input dulu, lalu yang lebih pendek.
+func matchEntries(words []acWord, tokens []string, norm string, aud models.Audience, limit int) []models.AutocompleteItem {
+	key := tokens[0]
+	for _, t := range tokens[1:] {
+		if len(t) > len(key) {
+			key = t
+		}
+	}
+
+	seen := map[*acEntry]bool{}
+	var found []*acEntry
+	for i := sort.Search(len(words), func(i int) bool { return words[i].word >= key }); i < len(words) && strings.HasPrefix(words[i].word, key); i++ {
+		e := words[i].entry
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		if matchesAllTokens(e.words, tokens) && e.visibleTo(aud) {
+			found = append(found, e)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		pi, pj := strings.HasPrefix(found[i].norm, norm), strings.HasPrefix(found[j].norm, norm)
+		if pi != pj {
+			return pi
+		}
+		if len(found[i].norm) != len(found[j].norm) {
+			return len(found[i].norm) < len(found[j].norm)
+		}
+		return found[i].norm < found[j].norm
+	})
+
+	out := []models.AutocompleteItem{}
+	for _, e := range found {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, e.item)
+	}
+	return out
+}
+
+func (e *acEntry) visibleTo(aud models.Audience) bool {
+	for _, t := range e.targets {
+		if !aud.Allows(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAllTokens(words, tokens []string) bool {
+	for _, t := range tokens {
+		ok := false
+		for _, w := range words {
+			if strings.HasPrefix(w, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRecent: query terakhir userID yang diawali input, paling sering dipakai dulu
+func (a *Autocompleter) matchRecent(userID int64, norm string, limit int) []models.AutocompleteItem {
+	a.recentMu.Lock()
+	var found []recentQuery
+	for _, rq := range a.recent[userID] {
+		if rq.norm != norm && strings.HasPrefix(rq.norm, norm) {
+			found = append(found, *rq)
+		}
+	}
+	a.recentMu.Unlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].count != found[j].count {
+			return found[i].count > found[j].count
+		}
+		return found[i].last.After(found[j].last)
+	})
+
+	out := []models.AutocompleteItem{}
+	for _, rq := range found {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, models.AutocompleteItem{Text: rq.text})
+	}
+	return out
+}
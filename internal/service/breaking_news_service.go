@@ -3,34 +3,281 @@ package service
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 )
 
 type BreakingNewsService struct {
-	repo repository.BreakingNewsRepository
+	repo        repository.BreakingNewsRepository
+	productRepo repository.ProductRepository
+	s2Repo      repository.S2NodeRepository
+	policy      *ContentPolicy
+	events      *EventHub
 }
 
-func NewBreakingNewsService(r repository.BreakingNewsRepository) *BreakingNewsService {
-	return &BreakingNewsService{repo: r}
+func NewBreakingNewsService(
+	r repository.BreakingNewsRepository,
+	productRepo repository.ProductRepository,
+	s2Repo repository.S2NodeRepository,
+	policy *ContentPolicy,
+	events *EventHub,
+) *BreakingNewsService {
+	return &BreakingNewsService{repo: r, productRepo: productRepo, s2Repo: s2Repo, policy: policy, events: events}
+}
+
+func (s *BreakingNewsService) publish(action string, b *models.BreakingNews) {
+	s.events.Publish(models.EventBreakingNews, breakingNewsEvent(action, b))
+}
+
+func breakingNewsEvent(action string, b *models.BreakingNews) models.ChangeEvent {
+	return models.ChangeEvent{Action: action, ID: b.ID, Kind: b.Kind, Severity: b.Severity}
 }
 
 func (s *BreakingNewsService) Create(kind models.ContentKind, productID int64, title string) (int64, error) {
 	b := &models.BreakingNews{
 		Kind:      kind,
-		ProductID: productID,
+		ProductID: &productID,
 		Title:     title,
 		IsActive:  true,
 	}
-	return s.repo.Create(b)
+	id, err := s.repo.Create(b)
+	if err != nil {
+		return 0, err
+	}
+	b.ID = id
+	s.publish(models.ActionCreated, b)
+	return id, nil
+}
+
+// CreateAnnouncement = pengumuman tanpa product (mis. "core banking down,
+// pakai form manual"), tampil di feed yang sama dengan breaking news product.
+func (s *BreakingNewsService) CreateAnnouncement(b *models.BreakingNews) (int64, error) {
+	b.Kind = models.KindAnnouncement
+	b.ProductID = nil
+	if err := s.validateAnnouncement(b); err != nil {
+		return 0, err
+	}
+	id, err := s.repo.Create(b)
+	if err != nil {
+		return 0, err
+	}
+	b.ID = id
+	s.publish(models.ActionCreated, b)
+	return id, nil
+}
+
+var ErrBreakingNewsNotFound = errors.New("breaking news tidak ditemukan")
+
+func validateBreakingNews(b *models.BreakingNews) error {
+	verr := &ValidationError{}
+	b.Title = strings.TrimSpace(b.Title)
+	if b.Title == "" {
+		verr.add("title", "is required")
+	}
+	if b.Severity == "" {
+		b.Severity = models.SeverityInfo
+	}
+	switch b.Severity {
+	case models.SeverityInfo, models.SeverityWarning, models.SeverityCritical:
+	default:
+		verr.add("severity", "must be one of: info, warning, critical")
+	}
+	if b.StartsAt != nil && b.EndsAt != nil && !b.EndsAt.After(*b.StartsAt) {
+		verr.add("ends_at", "must be after starts_at")
+	}
+	return verr.orNil()
+}
+
+// validateAnnouncement: body disanitasi seperti konten product, link
+// maksimal satu (product/script atau node S2) dan harus ada
+func (s *BreakingNewsService) validateAnnouncement(b *models.BreakingNews) error {
+	if err := validateBreakingNews(b); err != nil {
+		return err
+	}
+	body, err := s.policy.ValidateContent(b.Title, b.Body)
+	if err != nil {
+		return err
+	}
+	b.Body = body
+
+	verr := &ValidationError{}
+	if b.LinkProductID != nil && b.LinkS2NodeID != nil {
+		verr.add("link", "pilih salah satu: product/script atau node S2")
+	}
+	if b.LinkProductID != nil {
+		if _, err := s.productRepo.GetByID(*b.LinkProductID); err != nil {
+			verr.add("link_product_id", "product/script %d not found", *b.LinkProductID)
+		}
+	}
+	if b.LinkS2NodeID != nil {
+		if _, err := s.s2Repo.GetByID(*b.LinkS2NodeID); err != nil {
+			verr.add("link_s2_node_id", "node S2 %d not found", *b.LinkS2NodeID)
+		}
+	}
+	return verr.orNil()
+}
+
+// Get = item apa adanya (dasar partial update di handler)
+func (s *BreakingNewsService) Get(id int64) (*models.BreakingNews, error) {
+	b, err := s.repo.GetByID(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrBreakingNewsNotFound
+	}
+	return b, err
+}
+
+// Update mengganti title, status aktif, severity & jadwal tayang.
+// Body & link hanya berlaku untuk announcement.
+func (s *BreakingNewsService) Update(b *models.BreakingNews) error {
+	cur, err := s.repo.GetByID(b.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBreakingNewsNotFound
+		}
+		return err
+	}
+	if cur.Kind == models.KindAnnouncement {
+		err = s.validateAnnouncement(b)
+	} else {
+		b.Body, b.LinkProductID, b.LinkS2NodeID = nil, nil, nil
+		err = validateBreakingNews(b)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Update(b); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBreakingNewsNotFound
+		}
+		return err
+	}
+	b.Kind = cur.Kind
+	s.publish(models.ActionUpdated, b)
+	return nil
+}
+
+// Acknowledge = user menyatakan sudah membaca item yang sedang tayang
+// (idempotent per versi). Item untuk tim lain dianggap tidak ada.
+func (s *BreakingNewsService) Acknowledge(id, userID int64, aud models.Audience) error {
+	b, err := s.repo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBreakingNewsNotFound
+		}
+		return err
+	}
+	visible, err := s.repo.Visible(id, aud)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return ErrBreakingNewsNotFound
+	}
+	verr := &ValidationError{}
+	if !b.IsMandatory {
+		verr.add("id", "item ini tidak wajib di-acknowledge")
+	}
+	// hanya item yang sedang tayang (aktif & dalam jadwal)
+	now := time.Now()
+	if !b.IsActive || (b.StartsAt != nil && b.StartsAt.After(now)) || (b.EndsAt != nil && !b.EndsAt.After(now)) {
+		verr.add("id", "item ini tidak sedang tayang")
+	}
+	if err := verr.orNil(); err != nil {
+		return err
+	}
+	return s.repo.Acknowledge(id, userID)
+}
+
+// ListUnacknowledged = item wajib yang sedang tayang & belum dibaca user
+func (s *BreakingNewsService) ListUnacknowledged(userID int64, aud models.Audience, page models.PageRequest) (*models.Page[*models.BreakingNews], error) {
+	if err := checkSort(page, breakingNewsSorts...); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.ListUnacknowledged(userID, aud, page)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
 }
 
+// AckSummary = rekap acknowledgement semua item wajib (untuk supervisor)
+func (s *BreakingNewsService) AckSummary() ([]*models.BreakingNewsAckSummary, error) {
+	list, err := s.repo.AckSummary()
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []*models.BreakingNewsAckSummary{}
+	}
+	return list, nil
+}
+
+// AckReport = siapa yang sudah & belum acknowledge satu item
+func (s *BreakingNewsService) AckReport(id int64) (*models.BreakingNewsAckReport, error) {
+	b, err := s.repo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBreakingNewsNotFound
+		}
+		return nil, err
+	}
+	users, err := s.repo.AckUsers(id)
+	if err != nil {
+		return nil, err
+	}
+	rep := &models.BreakingNewsAckReport{Item: b, Users: []*models.BreakingNewsAck{}}
+	for _, u := range users {
+		rep.Users = append(rep.Users, u)
+		rep.Agents++
+		if u.AcknowledgedAt != nil {
+			rep.Acknowledged++
+		}
+	}
+	return rep, nil
+}
+
+// Delete ditolak kalau item sudah pernah di-acknowledge: catatannya bukti
+// agent sudah membaca, jadi item cukup dinonaktifkan
 func (s *BreakingNewsService) Delete(id int64) error {
-	return s.repo.Delete(id)
+	acked, err := s.repo.HasAcks(id)
+	if err != nil {
+		return err
+	}
+	if acked {
+		verr := &ValidationError{}
+		verr.add("id", "item sudah di-acknowledge agent; nonaktifkan saja supaya catatannya tetap ada")
+		return verr
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.publish(models.ActionDeleted, &models.BreakingNews{ID: id})
+	return nil
 }
 
-func (s *BreakingNewsService) ListActive() ([]*models.BreakingNews, error) {
-	return s.repo.ListActive()
+var breakingNewsSorts = []string{models.SortCreatedAt, models.SortTitle, models.SortSeverity}
+
+// ListActive = item yang sedang tayang untuk aud; severity tertinggi selalu di depan
+func (s *BreakingNewsService) ListActive(aud models.Audience, page models.PageRequest) (*models.Page[*models.BreakingNews], error) {
+	if err := checkSort(page, breakingNewsSorts...); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.ListActive(aud, page)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
 }
 
-func (s *BreakingNewsService) ListAll() ([]*models.BreakingNews, error) {
-	return s.repo.ListAll()
+func (s *BreakingNewsService) ListAll(page models.PageRequest) (*models.Page[*models.BreakingNews], error) {
+	if err := checkSort(page, breakingNewsSorts...); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.ListAll(page)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
 }
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxTenorMonths = 360
+	// batas atas plafon supaya total bayar (bunga s/d 100%/tahun x 30 tahun)
+	// tetap presisi di float64 dan muat di int64 saat diformat
+	maxPrincipal = 1_000_000_000_000
+)
+
+type CalculatorService struct {
+	productRepo repository.ProductRepository
+	rateSvc     *RateService
+}
+
+func NewCalculatorService(productRepo repository.ProductRepository, rateSvc *RateService) *CalculatorService {
+	return &CalculatorService{productRepo: productRepo, rateSvc: rateSvc}
+}
+
+// parameter yang boleh di-override oleh tabel rate effective-dated
+var datedRateKeys = []string{models.AttrInterestRate, models.AttrAdminFee, models.AttrProvisionFee}
+
+// CalculateLoan menghitung estimasi cicilan. Rate, metode, biaya dan batas
+// plafon/tenor diambil dari atribut product (kalau product_slug diisi).
+// Product bertarget tim lain dianggap tidak ada.
+func (s *CalculatorService) CalculateLoan(req models.LoanRequest, aud models.Audience) (*models.LoanCalculation, error) {
+	verr := &ValidationError{}
+	// pokok dibulatkan ke rupiah penuh dulu; validasi memakai nilai yang dihitung
+	req.Principal = math.Round(req.Principal)
+	if req.Principal <= 0 {
+		verr.add("principal", "must be greater than 0")
+	} else if req.Principal > maxPrincipal {
+		verr.add("principal", "must be at most %s", strconv.FormatFloat(maxPrincipal, 'f', -1, 64))
+	}
+	if req.TenorMonths <= 0 || req.TenorMonths > maxTenorMonths {
+		verr.add("tenor_months", "must be between 1 and %d", maxTenorMonths)
+	}
+
+	res := &models.LoanCalculation{
+		Method:      req.Method,
+		Principal:   req.Principal,
+		TenorMonths: req.TenorMonths,
+	}
+
+	var attrs map[string]any
+	if req.ProductSlug != "" {
+		p, err := s.productRepo.GetBySlug(models.ContentKindProduct, req.ProductSlug)
+		if err != nil {
+			return nil, fmt.Errorf("product tidak ditemukan: %s", req.ProductSlug)
+		}
+		if ok, err := s.productRepo.Visible(p.ID, aud); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, fmt.Errorf("product tidak ditemukan: %s", req.ProductSlug)
+		}
+		res.ProductSlug = p.Slug
+		res.ProductTitle = p.Title
+
+		// nilai dari tabel rate yang berlaku di tanggal pengajuan menang atas atribut
+		attrs = make(map[string]any, len(p.Attributes))
+		for k, v := range p.Attributes {
+			attrs[k] = v
+		}
+		res.AsOf = req.AsOf
+		if res.AsOf == "" {
+			res.AsOf = time.Now().Format(dateLayout)
+		}
+		for _, key := range datedRateKeys {
+			v, err := s.rateSvc.AsOf(p.ID, key, res.AsOf, models.AudienceAll)
+			if err == nil {
+				attrs[key] = v.Value
+			} else if err != ErrRateNotFound {
+				verr.add("as_of", "%s", err.Error())
+				break
+			}
+		}
+	}
+
+	switch {
+	case req.AnnualRate != nil:
+		res.AnnualRate = *req.AnnualRate
+	case attrNumber(attrs, models.AttrInterestRate) != nil:
+		res.AnnualRate = *attrNumber(attrs, models.AttrInterestRate)
+	default:
+		verr.add("annual_rate", "is required when product has no %s", models.AttrInterestRate)
+	}
+	if res.AnnualRate < 0 || res.AnnualRate > 100 {
+		verr.add("annual_rate", "must be between 0 and 100")
+	}
+
+	if res.Method == "" {
+		if m, ok := attrs[models.AttrInterestMethod].(string); ok {
+			res.Method = models.LoanMethod(m)
+		} else {
+			res.Method = models.LoanAnnuity
+		}
+	}
+	if res.Method != models.LoanAnnuity && res.Method != models.LoanFlat {
+		verr.add("method", "must be one of: annuity, flat")
+	}
+
+	checkRange(verr, "tenor_months", float64(req.TenorMonths), attrs, models.AttrTenorMin, models.AttrTenorMax)
+	checkRange(verr, "principal", req.Principal, attrs, models.AttrPrincipalMin, models.AttrPrincipalMax)
+
+	if err := verr.orNil(); err != nil {
+		return nil, err
+	}
+
+	schedule := buildSchedule(res.Method, res.Principal, res.AnnualRate, res.TenorMonths)
+	for _, row := range schedule {
+		res.TotalInterest += row.Interest
+	}
+	res.MonthlyInstallment = schedule[0].Installment
+
+	if fee := attrNumber(attrs, models.AttrAdminFee); fee != nil && *fee > 0 {
+		res.Fees = append(res.Fees, models.LoanFee{Name: "Biaya administrasi", Amount: math.Round(*fee)})
+	}
+	if pct := attrNumber(attrs, models.AttrProvisionFee); pct != nil && *pct > 0 {
+		res.Fees = append(res.Fees, models.LoanFee{Name: "Biaya provisi", Amount: math.Round(res.Principal * *pct / 100)})
+	}
+	for i := range res.Fees {
+		res.TotalFees += res.Fees[i].Amount
+		res.Fees[i].Formatted = FormatRupiah(res.Fees[i].Amount)
+	}
+	if res.Fees == nil {
+		res.Fees = []models.LoanFee{}
+	}
+
+	res.TotalPayment = res.Principal + res.TotalInterest + res.TotalFees
+	res.Formatted = map[string]string{
+		"principal":           FormatRupiah(res.Principal),
+		"monthly_installment": FormatRupiah(res.MonthlyInstallment),
+		"total_interest":      FormatRupiah(res.TotalInterest),
+		"total_fees":          FormatRupiah(res.TotalFees),
+		"total_payment":       FormatRupiah(res.TotalPayment),
+	}
+	if req.IncludeSchedule {
+		res.Schedule = schedule
+	}
+	return res, nil
+}
+
+// buildSchedule: tabel angsuran per bulan, dibulatkan ke rupiah.
+// Selisih pembulatan ditaruh di bulan terakhir supaya sisa pokok jadi 0.
+func buildSchedule(method models.LoanMethod, principal, annualRate float64, tenor int) []models.LoanScheduleRow {
+	r := annualRate / 100 / 12
+	rows := make([]models.LoanScheduleRow, 0, tenor)
+	balance := principal
+
+	var installment float64
+	switch {
+	case method == models.LoanFlat:
+		installment = math.Round(principal/float64(tenor) + principal*r)
+	case r == 0:
+		installment = math.Round(principal / float64(tenor))
+	default:
+		installment = math.Round(principal * r / (1 - math.Pow(1+r, -float64(tenor))))
+	}
+
+	for m := 1; m <= tenor; m++ {
+		var interest float64
+		if method == models.LoanFlat {
+			interest = math.Round(principal * r)
+		} else {
+			interest = math.Round(balance * r)
+		}
+		pay := installment - interest
+		if m == tenor || pay > balance {
+			pay = balance
+		}
+		balance -= pay
+		rows = append(rows, models.LoanScheduleRow{
+			Month:       m,
+			Installment: pay + interest,
+			Principal:   pay,
+			Interest:    interest,
+			Balance:     balance,
+		})
+	}
+	return rows
+}
+
+func attrNumber(attrs map[string]any, key string) *float64 {
+	if f, ok := attrs[key].(float64); ok {
+		return &f
+	}
+	return nil
+}
+
+func checkRange(verr *ValidationError, field string, v float64, attrs map[string]any, minKey, maxKey string) {
+	if lo := attrNumber(attrs, minKey); lo != nil && v < *lo {
+		verr.add(field, "must be at least %s for this product", strconv.FormatFloat(*lo, 'f', -1, 64))
+	}
+	if hi := attrNumber(attrs, maxKey); hi != nil && v > *hi {
+		verr.add(field, "must be at most %s for this product", strconv.FormatFloat(*hi, 'f', -1, 64))
+	}
+}
+
+// FormatRupiah: 1234567.4 -> "Rp 1.234.567"
+func FormatRupiah(v float64) string {
+	neg := v < 0
+	digits := strconv.FormatInt(int64(math.Round(math.Abs(v))), 10)
+
+	var b strings.Builder
+	for i, ch := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(ch)
+	}
+	if neg {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
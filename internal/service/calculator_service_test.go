@@ -0,0 +1,143 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBuildSchedule(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        models.LoanMethod
+		principal     float64
+		rate          float64
+		tenor         int
+		installment   float64 // angsuran bulan pertama
+		totalInterest float64
+	}{
+		{
+			name:      "anuitas 12jt 12% 12 bulan",
+			method:    models.LoanAnnuity,
+			principal: 12_000_000, rate: 12, tenor: 12,
+			installment:   1_066_185,
+			totalInterest: 794_226,
+		},
+		{
+			name:      "flat 12jt 12% 12 bulan",
+			method:    models.LoanFlat,
+			principal: 12_000_000, rate: 12, tenor: 12,
+			installment:   1_120_000,
+			totalInterest: 1_440_000,
+		},
+		{
+			name:      "bunga 0 sisa pembulatan di bulan terakhir",
+			method:    models.LoanAnnuity,
+			principal: 1_000_000, rate: 0, tenor: 3,
+			installment:   333_333,
+			totalInterest: 0,
+		},
+		{
+			name:      "anuitas 1 bulan",
+			method:    models.LoanAnnuity,
+			principal: 5_000_000, rate: 12, tenor: 1,
+			installment:   5_050_000,
+			totalInterest: 50_000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := buildSchedule(tt.method, tt.principal, tt.rate, tt.tenor)
+			if len(rows) != tt.tenor {
+				t.Fatalf("len(rows) = %d, want %d", len(rows), tt.tenor)
+			}
+			if rows[0].Installment != tt.installment {
+				t.Errorf("installment = %v, want %v", rows[0].Installment, tt.installment)
+			}
+
+			var principal, interest float64
+			for i, r := range rows {
+				if r.Month != i+1 {
+					t.Errorf("rows[%d].Month = %d", i, r.Month)
+				}
+				if r.Installment != r.Principal+r.Interest {
+					t.Errorf("rows[%d]: installment %v != principal %v + interest %v", i, r.Installment, r.Principal, r.Interest)
+				}
+				principal += r.Principal
+				interest += r.Interest
+			}
+			if last := rows[len(rows)-1].Balance; last != 0 {
+				t.Errorf("final balance = %v, want 0", last)
+			}
+			if principal != tt.principal {
+				t.Errorf("sum principal = %v, want %v", principal, tt.principal)
+			}
+			if interest != tt.totalInterest {
+				t.Errorf("total interest = %v, want %v", interest, tt.totalInterest)
+			}
+		})
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "Rp 0"},
+		{999, "Rp 999"},
+		{1000, "Rp 1.000"},
+		{1234567.4, "Rp 1.234.567"},
+		{1234567.5, "Rp 1.234.568"},
+		{-1500, "-Rp 1.500"},
+		{maxPrincipal, "Rp 1.000.000.000.000"},
+	}
+	for _, tt := range tests {
+		if got := FormatRupiah(tt.v); got != tt.want {
+			t.Errorf("FormatRupiah(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateLoanPrincipalBounds(t *testing.T) {
+	s := NewCalculatorService(nil, nil)
+	rate := 10.0
+
+	tests := []struct {
+		name      string
+		principal float64
+		ok        bool
+	}{
+		{"dibulatkan jadi 0", 0.4, false},
+		{"negatif", -1_000_000, false},
+		{"di atas batas", maxPrincipal + 1, false},
+		{"sangat besar", 1e300, false},
+		{"tepat di batas", maxPrincipal, true},
+		{"dibulatkan ke atas", 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.CalculateLoan(models.LoanRequest{
+				Principal:   tt.principal,
+				TenorMonths: 12,
+				AnnualRate:  &rate,
+			}, models.AudienceAll)
+
+			var verr *ValidationError
+			if !tt.ok {
+				if !errors.As(err, &verr) || verr.Fields[0].Field != "principal" {
+					t.Fatalf("err = %v, want validation error on principal", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got := res.Formatted["total_payment"]; !strings.HasPrefix(got, "Rp ") {
+				t.Errorf("formatted total_payment = %q", got)
+			}
+		})
+	}
+}
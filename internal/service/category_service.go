@@ -3,16 +3,20 @@ package service
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 )
 
 type CategoryService struct {
-	repo repository.CategoryRepository
+	repo         repository.CategoryRepository
+	autocomplete *Autocompleter // boleh nil (dipakai internal cuma untuk path)
+	events       *EventHub      // boleh nil
 }
 
-func NewCategoryService(r repository.CategoryRepository) *CategoryService {
-	return &CategoryService{repo: r}
+func NewCategoryService(r repository.CategoryRepository, autocomplete *Autocompleter, events *EventHub) *CategoryService {
+	return &CategoryService{repo: r, autocomplete: autocomplete, events: events}
 }
 
 func normalizeName(s string) string {
@@ -24,41 +28,318 @@ func normalizeName(s string) string {
 	return s
 }
 
-func (s *CategoryService) Create(kind models.ContentKind, name string, parentID *int64) (int64, error) {
+var ErrCategoryNotFound = errors.New("kategori tidak ditemukan")
+
+// categoryError: error aturan struktur dari repo -> ValidationError pada field
+func categoryError(err error, field string) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrCategoryNotFound
+	case errors.Is(err, repository.ErrCategorySiblingExists),
+		errors.Is(err, repository.ErrCategoryCycle),
+		errors.Is(err, repository.ErrCategoryKindMismatch),
+		errors.Is(err, repository.ErrCategoryOrderMismatch),
+		errors.Is(err, repository.ErrCategoryNotEmpty),
+		errors.Is(err, repository.ErrCategoryNotArchived),
+		errors.Is(err, repository.ErrCategoryParentArchived),
+		errors.Is(err, repository.ErrCategoryTargetsLost),
+		errors.Is(err, repository.ErrProductSlugTaken):
+		verr := &ValidationError{}
+		verr.add(field, "%s", err.Error())
+		return verr
+	}
+	return err
+}
+
+const (
+	maxCategoryDescription = 500
+	maxCategoryIcon        = 64
+)
+
+// CategoryMeta = field tampilan opsional. nil = tidak diubah (saat update),
+// string kosong = dikosongkan.
+type CategoryMeta struct {
+	Description *string
+	Icon        *string
+}
+
+// apply menulis meta ke c (trim, kosong -> NULL) + cek panjang
+func (m CategoryMeta) apply(c *models.Category) error {
+	verr := &ValidationError{}
+	if m.Description != nil {
+		c.Description = optionalText(*m.Description)
+		if c.Description != nil && len([]rune(*c.Description)) > maxCategoryDescription {
+			verr.add("description", "maksimal %d karakter", maxCategoryDescription)
+		}
+	}
+	if m.Icon != nil {
+		c.Icon = optionalText(*m.Icon)
+		if c.Icon != nil && len(*c.Icon) > maxCategoryIcon {
+			verr.add("icon", "maksimal %d karakter", maxCategoryIcon)
+		}
+	}
+	return verr.orNil()
+}
+
+func optionalText(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (s *CategoryService) Create(kind models.ContentKind, name string, parentID *int64, meta CategoryMeta) (int64, error) {
 	name = normalizeName(name)
 	if name == "" {
 		return 0, fmt.Errorf("name is required")
 	}
 	c := &models.Category{Kind: kind, Name: name, ParentID: parentID}
-	return s.repo.Create(c)
+	if err := meta.apply(c); err != nil {
+		return 0, err
+	}
+	id, err := s.repo.Create(c)
+	if err != nil {
+		return 0, categoryError(err, "name")
+	}
+	s.autocomplete.MarkDirty()
+	return id, nil
+}
+
+// Update mengganti nama (tetap unik di level yang sama) + meta yang dikirim
+func (s *CategoryService) Update(id int64, name string, meta CategoryMeta) error {
+	name = normalizeName(name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	c, err := s.repo.GetByID(id)
+	if err != nil {
+		return categoryError(err, "name")
+	}
+	c.Name = name
+	if err := meta.apply(c); err != nil {
+		return err
+	}
+	if err := s.repo.Update(c); err != nil {
+		return categoryError(err, "name")
+	}
+	s.autocomplete.MarkDirty()
+	return nil
+}
+
+// Reorder menyimpan urutan manual sub-kategori parentID (nil = root).
+// ids harus berisi semua sub-kategori di level itu.
+func (s *CategoryService) Reorder(kind models.ContentKind, parentID *int64, ids []int64) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("ids is required")
+	}
+	if err := s.repo.Reorder(kind, parentID, ids); err != nil {
+		return categoryError(err, "ids")
+	}
+	return nil
+}
+
+// Move memindah kategori (beserta subtree) ke parent lain; nil = jadi root
+func (s *CategoryService) Move(id int64, parentID *int64) error {
+	if parentID != nil && *parentID == id {
+		return categoryError(repository.ErrCategoryCycle, "parent_id")
+	}
+	if err := s.repo.Move(id, parentID); err != nil {
+		return categoryError(err, "parent_id")
+	}
+	s.autocomplete.MarkDirty()
+	return nil
+}
+
+// Merge menggabungkan source ke target: product & sub-kategori pindah,
+// source dihapus
+func (s *CategoryService) Merge(sourceID, targetID int64) (*models.CategoryMergeResult, error) {
+	if sourceID == targetID {
+		return nil, categoryError(repository.ErrCategoryCycle, "target_id")
+	}
+	res, err := s.repo.Merge(sourceID, targetID)
+	if err != nil {
+		return nil, categoryError(err, "target_id")
+	}
+	s.autocomplete.MarkDirty()
+	// konten source sekarang ikut target tim kategori tujuan
+	s.events.Publish(models.EventResync, struct{}{})
+	return res, nil
+}
+
+// Impact = preview hapus: semua turunan & product di dalamnya (dengan path)
+func (s *CategoryService) Impact(id int64) (*models.CategoryImpact, error) {
+	impact, err := s.repo.Impact(id)
+	if err != nil {
+		return nil, categoryError(err, "id")
+	}
+	ids := []int64{id}
+	for _, c := range impact.Descendants {
+		ids = append(ids, c.ID)
+	}
+	paths, err := s.repo.Paths(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range impact.Products {
+		p.CategoryPath = paths[p.CategoryID]
+	}
+	return impact, nil
 }
 
-func (s *CategoryService) Delete(id int64) error {
-	return s.repo.Delete(id)
+// Delete menghapus kategori dengan strategi yang dipilih admin:
+// block (hanya kalau kosong), move (product ke targetID) atau archive.
+func (s *CategoryService) Delete(id int64, strategy string, targetID *int64) (*models.CategoryDeleteResult, error) {
+	var (
+		res = &models.CategoryDeleteResult{Strategy: strategy}
+		err error
+	)
+	switch strategy {
+	case models.CategoryDeleteBlock:
+		if err = s.repo.Delete(id); err == nil {
+			res.DeletedCategories = 1
+		}
+		err = categoryError(err, "strategy")
+	case models.CategoryDeleteMove:
+		if targetID == nil {
+			verr := &ValidationError{}
+			verr.add("target_id", "wajib diisi untuk strategi move")
+			return nil, verr
+		}
+		res, err = s.repo.DeleteMoving(id, *targetID)
+		err = categoryError(err, "target_id")
+	case models.CategoryDeleteArchive:
+		res, err = s.repo.Archive(id)
+		err = categoryError(err, "strategy")
+	default:
+		verr := &ValidationError{}
+		verr.add("strategy", "harus salah satu dari: %s, %s, %s",
+			models.CategoryDeleteBlock, models.CategoryDeleteMove, models.CategoryDeleteArchive)
+		return nil, verr
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.autocomplete.MarkDirty()
+	if strategy == models.CategoryDeleteMove {
+		s.events.Publish(models.EventResync, struct{}{})
+	}
+	return res, nil
+}
+
+// Restore = kebalikan Delete strategi archive (kategori, turunan & product-nya)
+func (s *CategoryService) Restore(id int64) (*models.CategoryRestoreResult, error) {
+	res, err := s.repo.Restore(id)
+	if err != nil {
+		return nil, categoryError(err, "id")
+	}
+	s.autocomplete.MarkDirty()
+	return res, nil
 }
 
 func (s *CategoryService) GetByID(id int64) (*models.Category, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *CategoryService) ListByParent(kind models.ContentKind, parentID *int64) ([]*models.Category, error) {
-	return s.repo.ListByParent(kind, parentID)
+// ListByParent + jumlah konten per node (termasuk sub-kategori)
+func (s *CategoryService) ListByParent(kind models.ContentKind, parentID *int64, aud models.Audience) ([]*models.Category, error) {
+	list, err := s.repo.ListByParent(kind, parentID, aud)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(list))
+	for _, c := range list {
+		ids = append(ids, c.ID)
+	}
+	counts, err := s.repo.Counts(ids, aud)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range list {
+		c.Counts = counts[c.ID]
+		if c.Counts == nil {
+			c.Counts = &models.CategoryCounts{}
+		}
+	}
+	return list, nil
+}
+
+// Tree = semua kategori satu kind dalam bentuk nested (urut sort_order)
+// beserta jumlah konten per node, untuk halaman admin kategori.
+func (s *CategoryService) Tree(kind models.ContentKind, aud models.Audience) ([]*models.Category, error) {
+	all, err := s.repo.ListAll(aud)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		list []*models.Category
+		ids  []int64
+	)
+	byID := map[int64]*models.Category{}
+	for _, c := range all {
+		if c.Kind == kind {
+			list = append(list, c)
+			ids = append(ids, c.ID)
+			byID[c.ID] = c
+		}
+	}
+	counts, err := s.repo.Counts(ids, aud)
+	if err != nil {
+		return nil, err
+	}
+
+	// ListAll sudah urut sort_order, jadi urutan anak ikut terjaga
+	roots := []*models.Category{}
+	for _, c := range list {
+		c.Counts = counts[c.ID]
+		if c.Counts == nil {
+			c.Counts = &models.CategoryCounts{}
+		}
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots, nil
+}
+
+// PathFor = BuildPathString yang menyembunyikan kategori bertarget tim lain dari aud
+func (s *CategoryService) PathFor(categoryID int64, aud models.Audience) (string, error) {
+	ok, err := s.repo.Visible(categoryID, aud)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return s.BuildPathString(categoryID)
 }
 
 // build path string: root / ... / leaf
 func (s *CategoryService) BuildPathString(categoryID int64) (string, error) {
-	cur, err := s.repo.GetByID(categoryID)
+	paths, err := s.repo.Paths([]int64{categoryID})
 	if err != nil {
 		return "", err
 	}
-	names := []string{cur.Name}
-	for cur.ParentID != nil {
-		parent, err := s.repo.GetByID(*cur.ParentID)
-		if err != nil {
-			return "", err
+	path, ok := paths[categoryID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return path, nil
+}
+
+// Paths = path banyak kategori sekaligus (id duplikat/0 diabaikan)
+func (s *CategoryService) Paths(ids []int64) (map[int64]string, error) {
+	seen := make(map[int64]bool, len(ids))
+	uniq := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
 		}
-		names = append([]string{parent.Name}, names...)
-		cur = parent
 	}
-	return strings.Join(names, " / "), nil
+	return s.repo.Paths(uniq)
 }
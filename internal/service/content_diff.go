@@ -0,0 +1,168 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"fmt"
+	"strings"
+)
+
+const (
+	diffLabelWords   = 8
+	maxSummaryItems  = 5
+	imageBlockLabel  = "Gambar"
+	noChangesSummary = "tidak ada perubahan isi"
+)
+
+// diffBlocks menyejajarkan blok lama & baru (LCS by isi blok). Blok yang
+// hilang & muncul di posisi yang sama dengan type sama dianggap "changed".
+func diffBlocks(before, after []models.ContentBlock) []models.BlockDiff {
+	n, m := len(before), len(after)
+	keyA := make([]string, n)
+	keyB := make([]string, m)
+	for i, b := range before {
+		keyA[i] = blockKey(b)
+	}
+	for j, b := range after {
+		keyB[j] = blockKey(b)
+	}
+
+	// lcs[i][j] = panjang LCS before[i:] & after[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if keyA[i] == keyB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		out            []models.BlockDiff
+		removed, added []int
+	)
+	flush := func() {
+		k := 0
+		for ; k < len(removed) && k < len(added); k++ {
+			i, j := removed[k], added[k]
+			if before[i].Type != after[j].Type {
+				break
+			}
+			out = append(out, blockDiff(models.BlockChanged, before, after, i, j))
+		}
+		for _, i := range removed[k:] {
+			out = append(out, blockDiff(models.BlockRemoved, before, after, i, -1))
+		}
+		for _, j := range added[k:] {
+			out = append(out, blockDiff(models.BlockAdded, before, after, -1, j))
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && keyA[i] == keyB[j]:
+			flush()
+			out = append(out, blockDiff(models.BlockUnchanged, before, after, i, j))
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, j)
+			j++
+		default:
+			removed = append(removed, i)
+			i++
+		}
+	}
+	flush()
+	return out
+}
+
+func blockKey(b models.ContentBlock) string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return string(b.Type) + "\x00" + deref(b.Text) + "\x00" + deref(b.ImageURL) + "\x00" + deref(b.AltText)
+}
+
+// blockDiff: i / j = -1 kalau blok tidak ada di versi itu
+func blockDiff(op string, before, after []models.ContentBlock, i, j int) models.BlockDiff {
+	d := models.BlockDiff{Op: op}
+	if i >= 0 {
+		idx, b := i, before[i]
+		d.OldIndex, d.Old = &idx, &b
+		d.Label = blockLabel(b)
+	}
+	if j >= 0 {
+		idx, b := j, after[j]
+		d.NewIndex, d.New = &idx, &b
+		d.Label = blockLabel(b)
+	}
+	return d
+}
+
+func blockLabel(b models.ContentBlock) string {
+	switch {
+	case b.Type == models.ContentTypeText && b.Text != nil:
+		if s := firstWords(stripHTML(*b.Text), diffLabelWords); s != "" {
+			return s
+		}
+	case b.Type == models.ContentTypeImage && b.AltText != nil && strings.TrimSpace(*b.AltText) != "":
+		return imageBlockLabel + ": " + strings.TrimSpace(*b.AltText)
+	}
+	if b.Type == models.ContentTypeImage {
+		return imageBlockLabel
+	}
+	return "(kosong)"
+}
+
+// summarizeChange membuat ringkasan untuk ticker dari hasil diffBlocks
+func summarizeChange(titleBefore, titleAfter string, diff []models.BlockDiff) models.ChangeSummary {
+	sum := models.ChangeSummary{TitleChanged: titleBefore != titleAfter, Items: []string{}}
+	for _, d := range diff {
+		var verb string
+		switch d.Op {
+		case models.BlockChanged:
+			sum.Changed++
+			verb = "Diubah"
+		case models.BlockAdded:
+			sum.Added++
+			verb = "Ditambah"
+		case models.BlockRemoved:
+			sum.Removed++
+			verb = "Dihapus"
+		default:
+			continue
+		}
+		if len(sum.Items) < maxSummaryItems {
+			sum.Items = append(sum.Items, verb+": "+d.Label)
+		}
+	}
+
+	var parts []string
+	if sum.TitleChanged {
+		parts = append(parts, "judul diubah")
+	}
+	if sum.Changed > 0 {
+		parts = append(parts, fmt.Sprintf("%d blok diubah", sum.Changed))
+	}
+	if sum.Added > 0 {
+		parts = append(parts, fmt.Sprintf("%d blok ditambah", sum.Added))
+	}
+	if sum.Removed > 0 {
+		parts = append(parts, fmt.Sprintf("%d blok dihapus", sum.Removed))
+	}
+	sum.Text = noChangesSummary
+	if len(parts) > 0 {
+		sum.Text = strings.Join(parts, ", ")
+	}
+	return sum
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func textBlock(s string) models.ContentBlock {
+	return models.ContentBlock{Type: models.ContentTypeText, Text: &s}
+}
+
+func imageBlock(url, alt string) models.ContentBlock {
+	return models.ContentBlock{Type: models.ContentTypeImage, ImageURL: &url, AltText: &alt}
+}
+
+func TestDiffBlocks(t *testing.T) {
+	a, b, c := textBlock("<p>Bunga 5%</p>"), textBlock("Tenor 12 bulan"), textBlock("Biaya admin")
+	img := imageBlock("/static/a.png", "Tabel bunga")
+
+	tests := []struct {
+		name    string
+		before  []models.ContentBlock
+		after   []models.ContentBlock
+		ops     []string
+		labels  []string
+		summary string
+	}{
+		{
+			name:    "tanpa perubahan",
+			before:  []models.ContentBlock{a, b},
+			after:   []models.ContentBlock{a, b},
+			ops:     []string{models.BlockUnchanged, models.BlockUnchanged},
+			labels:  []string{"Bunga 5%", "Tenor 12 bulan"},
+			summary: noChangesSummary,
+		},
+		{
+			name:    "tambah di akhir",
+			before:  []models.ContentBlock{a},
+			after:   []models.ContentBlock{a, b},
+			ops:     []string{models.BlockUnchanged, models.BlockAdded},
+			labels:  []string{"Bunga 5%", "Tenor 12 bulan"},
+			summary: "1 blok ditambah",
+		},
+		{
+			name:    "hapus di tengah",
+			before:  []models.ContentBlock{a, img, b},
+			after:   []models.ContentBlock{a, b},
+			ops:     []string{models.BlockUnchanged, models.BlockRemoved, models.BlockUnchanged},
+			labels:  []string{"Bunga 5%", "Gambar: Tabel bunga", "Tenor 12 bulan"},
+			summary: "1 blok dihapus",
+		},
+		{
+			name:    "ubah teks di posisi sama",
+			before:  []models.ContentBlock{a, b},
+			after:   []models.ContentBlock{a, textBlock("Tenor 24 bulan")},
+			ops:     []string{models.BlockUnchanged, models.BlockChanged},
+			labels:  []string{"Bunga 5%", "Tenor 24 bulan"},
+			summary: "1 blok diubah",
+		},
+		{
+			name:    "urutan ditukar",
+			before:  []models.ContentBlock{a, b},
+			after:   []models.ContentBlock{b, a},
+			ops:     []string{models.BlockAdded, models.BlockUnchanged, models.BlockRemoved},
+			labels:  []string{"Tenor 12 bulan", "Bunga 5%", "Tenor 12 bulan"},
+			summary: "1 blok ditambah, 1 blok dihapus",
+		},
+		{
+			name:    "ganti type bukan diubah",
+			before:  []models.ContentBlock{a, c},
+			after:   []models.ContentBlock{a, img},
+			ops:     []string{models.BlockUnchanged, models.BlockRemoved, models.BlockAdded},
+			labels:  []string{"Bunga 5%", "Biaya admin", "Gambar: Tabel bunga"},
+			summary: "1 blok ditambah, 1 blok dihapus",
+		},
+		{
+			name:    "semua baru",
+			before:  nil,
+			after:   []models.ContentBlock{a, img},
+			ops:     []string{models.BlockAdded, models.BlockAdded},
+			labels:  []string{"Bunga 5%", "Gambar: Tabel bunga"},
+			summary: "2 blok ditambah",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffBlocks(tt.before, tt.after)
+			var ops, labels []string
+			for _, d := range diff {
+				ops = append(ops, d.Op)
+				labels = append(labels, d.Label)
+			}
+			if !reflect.DeepEqual(ops, tt.ops) {
+				t.Errorf("ops = %v, want %v", ops, tt.ops)
+			}
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("labels = %v, want %v", labels, tt.labels)
+			}
+			if got := summarizeChange("Judul", "Judul", diff).Text; got != tt.summary {
+				t.Errorf("summary = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+}
+
+func TestDiffBlocksIndexes(t *testing.T) {
+	a, b := textBlock("satu"), textBlock("dua")
+	diff := diffBlocks([]models.ContentBlock{a, b}, []models.ContentBlock{b})
+
+	if len(diff) != 2 {
+		t.Fatalf("len(diff) = %d, want 2", len(diff))
+	}
+	removed, kept := diff[0], diff[1]
+	if removed.Op != models.BlockRemoved || removed.OldIndex == nil || *removed.OldIndex != 0 || removed.NewIndex != nil {
+		t.Errorf("removed = %+v, want old index 0 only", removed)
+	}
+	if kept.Op != models.BlockUnchanged || *kept.OldIndex != 1 || *kept.NewIndex != 0 {
+		t.Errorf("kept = old %d new %d, want old 1 new 0", *kept.OldIndex, *kept.NewIndex)
+	}
+}
+
+func TestSummarizeChange(t *testing.T) {
+	var diff []models.BlockDiff
+	for i := 0; i < maxSummaryItems+2; i++ {
+		diff = append(diff, models.BlockDiff{Op: models.BlockAdded, Label: "blok"})
+	}
+	diff = append(diff,
+		models.BlockDiff{Op: models.BlockChanged, Label: "ubah"},
+		models.BlockDiff{Op: models.BlockUnchanged, Label: "tetap"},
+	)
+
+	sum := summarizeChange("Lama", "Baru", diff)
+	if !sum.TitleChanged || sum.Added != maxSummaryItems+2 || sum.Changed != 1 || sum.Removed != 0 {
+		t.Errorf("counts = %+v", sum)
+	}
+	if len(sum.Items) != maxSummaryItems {
+		t.Errorf("len(items) = %d, want %d", len(sum.Items), maxSummaryItems)
+	}
+	if sum.Items[0] != "Ditambah: blok" {
+		t.Errorf("items[0] = %q", sum.Items[0])
+	}
+	if want := "judul diubah, 1 blok diubah, 7 blok ditambah"; sum.Text != want {
+		t.Errorf("text = %q, want %q", sum.Text, want)
+	}
+
+	if got := summarizeChange("Sama", "Sama", nil); got.Text != noChangesSummary || got.Items == nil {
+		t.Errorf("empty summary = %+v", got)
+	}
+}
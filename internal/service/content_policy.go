@@ -0,0 +1,166 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLen     = 200
+	maxBlocks       = 100
+	maxTextBlockLen = 20000 // panjang teks polos per block
+	maxAltTextLen   = 300
+	maxS2LabelLen   = 200
+	maxS2BodyLen    = 20000
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError dikembalikan kalau input admin ditolak, berisi daftar
+// error per field supaya bisa ditampilkan di form.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return "validation failed"
+	}
+	return fmt.Sprintf("validation failed: %s %s", e.Fields[0].Field, e.Fields[0].Message)
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ContentPolicy memvalidasi & membersihkan konten yang disimpan admin
+// (blocks product/script dan body S2PASS) sebelum masuk DB.
+type ContentPolicy struct {
+	uploadPrefix string // contoh: http://localhost:8080/static/
+}
+
+func NewContentPolicy(baseURL string) *ContentPolicy {
+	return &ContentPolicy{uploadPrefix: strings.TrimRight(baseURL, "/") + "/static/"}
+}
+
+// gambar hanya boleh dari upload store kita sendiri
+func (p *ContentPolicy) isUploadURL(u string) bool {
+	if strings.Contains(u, "..") {
+		return false
+	}
+	return strings.HasPrefix(u, p.uploadPrefix) || strings.HasPrefix(u, "/static/")
+}
+
+func (p *ContentPolicy) SanitizeHTML(s string) string {
+	return sanitizeHTML(s, p.isUploadURL)
+}
+
+func checkTitle(verr *ValidationError, field, title string) {
+	t := strings.TrimSpace(title)
+	if t == "" {
+		verr.add(field, "is required")
+	} else if utf8.RuneCountInString(t) > maxTitleLen {
+		verr.add(field, "must be at most %d characters", maxTitleLen)
+	}
+}
+
+// ValidateContent mengecek title dan tiap block (type harus sesuai field yang
+// diisi, URL gambar harus dari upload store, batas panjang) lalu mengembalikan
+// salinan blocks dengan HTML teks yang sudah disanitasi.
+func (p *ContentPolicy) ValidateContent(title string, blocks []models.ContentBlock) ([]models.ContentBlock, error) {
+	verr := &ValidationError{}
+	checkTitle(verr, "title", title)
+	if len(blocks) > maxBlocks {
+		verr.add("blocks", "must contain at most %d blocks", maxBlocks)
+		return nil, verr
+	}
+
+	out := make([]models.ContentBlock, 0, len(blocks))
+	for i, b := range blocks {
+		field := fmt.Sprintf("blocks[%d]", i)
+		switch b.Type {
+		case models.ContentTypeText:
+			if b.ImageURL != nil && *b.ImageURL != "" {
+				verr.add(field+".imageUrl", "must be empty for text block")
+			}
+			if b.Text == nil || strings.TrimSpace(*b.Text) == "" {
+				verr.add(field+".text", "is required for text block")
+				continue
+			}
+			clean := p.SanitizeHTML(*b.Text)
+			if utf8.RuneCountInString(stripHTML(clean)) > maxTextBlockLen {
+				verr.add(field+".text", "must be at most %d characters", maxTextBlockLen)
+			}
+			out = append(out, models.ContentBlock{Type: b.Type, Text: &clean})
+
+		case models.ContentTypeImage:
+			if b.Text != nil && *b.Text != "" {
+				verr.add(field+".text", "must be empty for image block")
+			}
+			if b.ImageURL == nil || strings.TrimSpace(*b.ImageURL) == "" {
+				verr.add(field+".imageUrl", "is required for image block")
+				continue
+			}
+			u := strings.TrimSpace(*b.ImageURL)
+			if !p.isUploadURL(u) {
+				verr.add(field+".imageUrl", "must point to the upload store (%s)", p.uploadPrefix)
+			}
+			nb := models.ContentBlock{Type: b.Type, ImageURL: &u}
+			if b.AltText != nil {
+				alt := strings.TrimSpace(*b.AltText)
+				if utf8.RuneCountInString(alt) > maxAltTextLen {
+					verr.add(field+".altText", "must be at most %d characters", maxAltTextLen)
+				}
+				nb.AltText = &alt
+			}
+			out = append(out, nb)
+
+		default:
+			verr.add(field+".type", "must be one of: text, image")
+		}
+	}
+
+	if err := verr.orNil(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ValidateS2Node mengecek label/title dan membersihkan body HTML node S2PASS (in place).
+func (p *ContentPolicy) ValidateS2Node(n *models.S2Node) error {
+	verr := &ValidationError{}
+
+	label := strings.TrimSpace(n.Label)
+	if label == "" {
+		verr.add("label", "is required")
+	} else if utf8.RuneCountInString(label) > maxS2LabelLen {
+		verr.add("label", "must be at most %d characters", maxS2LabelLen)
+	}
+	n.Label = label
+
+	if n.Title != nil && utf8.RuneCountInString(*n.Title) > maxTitleLen {
+		verr.add("title", "must be at most %d characters", maxTitleLen)
+	}
+
+	if n.Body != nil {
+		clean := p.SanitizeHTML(*n.Body)
+		if utf8.RuneCountInString(stripHTML(clean)) > maxS2BodyLen {
+			verr.add("body", "must be at most %d characters", maxS2BodyLen)
+		}
+		n.Body = &clean
+	}
+
+	return verr.orNil()
+}
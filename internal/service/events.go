@@ -0,0 +1,130 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	eventBufferSize = 256 // event terakhir yang bisa di-replay
+	eventSubBuffer  = 64  // antrean per koneksi sebelum dianggap lambat
+)
+
+// EventHub = pub/sub in-memory untuk stream SSE /events. Event terakhir
+// disimpan di ring buffer supaya client yang reconnect dengan Last-Event-ID
+// bisa menyusul. Hanya berlaku untuk satu instance server.
+type EventHub struct {
+	mu     sync.Mutex
+	lastID int64
+	buf    []models.Event // ring buffer, buf[head] = event tertua
+	head   int
+	subs   map[*EventSub]struct{}
+}
+
+// EventSub = satu koneksi stream. C ditutup kalau subscriber terlalu lambat
+// (antrean penuh); client reconnect lalu menyusul lewat replay.
+type EventSub struct {
+	C         <-chan models.Event
+	ch        chan models.Event
+	userID    int64
+	sessionID int64
+	// Replay = event setelah Last-Event-ID yang masih ada di buffer
+	Replay []models.Event
+	// Resync = Last-Event-ID tidak bisa disusul (terlalu lama / server restart)
+	Resync bool
+}
+
+func NewEventHub() *EventHub {
+	// id dimulai dari waktu start supaya id dari proses sebelumnya
+	// (sebelum restart) selalu lebih kecil dan terdeteksi sebagai resync
+	return &EventHub{
+		lastID: time.Now().UnixMilli(),
+		subs:   map[*EventSub]struct{}{},
+	}
+}
+
+// Publish mengirim event ke semua user. Aman untuk nil.
+func (h *EventHub) Publish(typ string, data any) {
+	h.publish(models.Event{Type: typ, Data: data})
+}
+
+// PublishTo mengirim event hanya ke koneksi milik userID. Aman untuk nil.
+func (h *EventHub) PublishTo(userID int64, typ string, data any) {
+	h.publish(models.Event{Type: typ, Data: data, UserID: userID})
+}
+
+// PublishToSession mengirim event hanya ke koneksi yang dibuka dengan sesi
+// login sessionID (mis. logout di semua tab sesi itu). Aman untuk nil.
+func (h *EventHub) PublishToSession(userID, sessionID int64, typ string, data any) {
+	h.publish(models.Event{Type: typ, Data: data, UserID: userID, SessionID: sessionID})
+}
+
+func (h *EventHub) publish(ev models.Event) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	ev.ID = h.lastID
+	if len(h.buf) < eventBufferSize {
+		h.buf = append(h.buf, ev)
+	} else {
+		h.buf[h.head] = ev
+		h.head = (h.head + 1) % eventBufferSize
+	}
+
+	for sub := range h.subs {
+		if !sub.wants(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe mendaftarkan koneksi baru. lastEventID = 0 berarti koneksi
+// baru (tanpa replay).
+func (h *EventHub) Subscribe(userID, sessionID, lastEventID int64) *EventSub {
+	ch := make(chan models.Event, eventSubBuffer)
+	sub := &EventSub{C: ch, ch: ch, userID: userID, sessionID: sessionID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastEventID > 0 && lastEventID != h.lastID {
+		oldest := h.lastID - int64(len(h.buf)) + 1
+		if lastEventID < oldest-1 || lastEventID > h.lastID {
+			sub.Resync = true
+		} else {
+			for i := range h.buf {
+				ev := h.buf[(h.head+i)%len(h.buf)]
+				if ev.ID > lastEventID && sub.wants(ev) {
+					sub.Replay = append(sub.Replay, ev)
+				}
+			}
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *EventHub) Unsubscribe(sub *EventSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (s *EventSub) wants(ev models.Event) bool {
+	return (ev.UserID == 0 || ev.UserID == s.userID) &&
+		(ev.SessionID == 0 || ev.SessionID == s.sessionID)
+}
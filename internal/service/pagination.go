@@ -0,0 +1,16 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"strings"
+)
+
+// checkSort memastikan sort termasuk pilihan yang didukung endpoint
+func checkSort(page models.PageRequest, allowed ...string) error {
+	if containsString(allowed, page.Sort) {
+		return nil
+	}
+	verr := &ValidationError{}
+	verr.add("sort", "must be one of: %s (prefix - for descending)", strings.Join(allowed, ", "))
+	return verr
+}
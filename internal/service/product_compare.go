@@ -0,0 +1,207 @@
+package service
+
+import (
+	"bytes"
+	"cc-helper-backend/internal/models"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	minCompare = 2
+	maxCompare = 5
+)
+
+// Compare menyejajarkan atribut & bagian konten beberapa product (by slug).
+func (s *ProductService) Compare(kind models.ContentKind, slugs []string, aud models.Audience) (*models.ProductComparison, error) {
+	if len(slugs) < minCompare || len(slugs) > maxCompare {
+		return nil, fmt.Errorf("jumlah slug harus %d sampai %d", minCompare, maxCompare)
+	}
+
+	products := make([]*models.Product, 0, len(slugs))
+	for _, slug := range slugs {
+		p, err := s.GetBySlug(kind, slug, aud)
+		if err != nil {
+			return nil, fmt.Errorf("%s tidak ditemukan: %s", kind, slug)
+		}
+		products = append(products, p)
+	}
+
+	res := &models.ProductComparison{}
+	for _, p := range products {
+		res.Products = append(res.Products, models.ComparedProduct{
+			ID:           p.ID,
+			Kind:         p.Kind,
+			Slug:         p.Slug,
+			Title:        p.Title,
+			CategoryPath: p.CategoryPath,
+		})
+	}
+
+	attrs, err := s.compareAttributes(products)
+	if err != nil {
+		return nil, err
+	}
+	res.Attributes = attrs
+	res.Sections = compareSections(products)
+	return res, nil
+}
+
+func (s *ProductService) compareAttributes(products []*models.Product) ([]*models.ComparisonAttribute, error) {
+	rows := map[string]*models.ComparisonAttribute{}
+	var order []string
+
+	for _, p := range products {
+		schema, err := s.attributeSvc.EffectiveSchema(p.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, def := range schema {
+			if _, ok := rows[def.Key]; ok {
+				continue
+			}
+			rows[def.Key] = &models.ComparisonAttribute{
+				Key:   def.Key,
+				Label: def.Label,
+				Type:  def.Type,
+				Unit:  def.Unit,
+			}
+			order = append(order, def.Key)
+		}
+	}
+
+	out := make([]*models.ComparisonAttribute, 0, len(order))
+	for _, key := range order {
+		row := rows[key]
+		for _, p := range products {
+			row.Values = append(row.Values, p.Attributes[key])
+		}
+		row.Differs = valuesDiffer(row.Values)
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+func valuesDiffer(values []any) bool {
+	for i := 1; i < len(values); i++ {
+		if fmt.Sprint(values[i]) != fmt.Sprint(values[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+type contentSection struct {
+	key   string
+	title string
+	html  string
+	text  string
+}
+
+// compareSections memecah text blocks tiap product jadi bagian per heading
+// (h1-h4) lalu menyejajarkan bagian dengan judul yang sama.
+func compareSections(products []*models.Product) []*models.ComparisonSection {
+	rows := map[string]*models.ComparisonSection{}
+	texts := map[string][]string{}
+	var order []string
+
+	for i, p := range products {
+		for _, sec := range splitSections(p.Blocks) {
+			row, ok := rows[sec.key]
+			if !ok {
+				row = &models.ComparisonSection{
+					Key:      sec.key,
+					Title:    sec.title,
+					Contents: make([]*string, len(products)),
+				}
+				rows[sec.key] = row
+				texts[sec.key] = make([]string, len(products))
+				order = append(order, sec.key)
+			}
+			h := sec.html
+			if row.Contents[i] != nil {
+				h = *row.Contents[i] + h
+			}
+			row.Contents[i] = &h
+			texts[sec.key][i] = strings.TrimSpace(texts[sec.key][i] + " " + sec.text)
+		}
+	}
+
+	out := make([]*models.ComparisonSection, 0, len(order))
+	for _, key := range order {
+		row := rows[key]
+		for i := range products {
+			if row.Contents[i] == nil || texts[key][i] != texts[key][0] {
+				row.Differs = true
+				break
+			}
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func splitSections(blocks []models.ContentBlock) []contentSection {
+	var (
+		out []contentSection
+		cur = contentSection{}
+		buf bytes.Buffer
+	)
+	flush := func() {
+		if buf.Len() > 0 || cur.title != "" {
+			cur.html = buf.String()
+			cur.text = strings.ToLower(stripHTML(cur.html))
+			out = append(out, cur)
+		}
+		buf.Reset()
+	}
+
+	ctx := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, b := range blocks {
+		if b.Type != models.ContentTypeText || b.Text == nil {
+			continue
+		}
+		nodes, err := html.ParseFragment(strings.NewReader(*b.Text), ctx)
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			if n.Type == html.ElementNode && isHeading(n.DataAtom) {
+				flush()
+				title := strings.TrimSpace(textContent(n))
+				cur = contentSection{key: sectionKey(title), title: title}
+				continue
+			}
+			_ = html.Render(&buf, n)
+		}
+	}
+	flush()
+	return out
+}
+
+func isHeading(a atom.Atom) bool {
+	return a == atom.H1 || a == atom.H2 || a == atom.H3 || a == atom.H4
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// "Syarat & Ketentuan:" dan "syarat & ketentuan" dianggap bagian yang sama
+func sectionKey(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.TrimRight(title, ": "))), " ")
+}
@@ -4,7 +4,9 @@ import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/repository"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
 	"strings"
 )
@@ -14,22 +16,41 @@ type ProductService struct {
 	categoryRepo     repository.CategoryRepository
 	categorySvc      *CategoryService
 	breakingNewsRepo repository.BreakingNewsRepository
+	changeRepo       repository.ContentChangeRepository
+	attributeSvc     *AttributeService
+	policy           *ContentPolicy
+	autocomplete     *Autocompleter
+	events           *EventHub
 }
 
 func NewProductService(
 	productRepo repository.ProductRepository,
 	categoryRepo repository.CategoryRepository,
 	breakingNewsRepo repository.BreakingNewsRepository,
+	changeRepo repository.ContentChangeRepository,
+	attributeSvc *AttributeService,
+	policy *ContentPolicy,
+	autocomplete *Autocompleter,
+	events *EventHub,
 ) *ProductService {
-	catSvc := NewCategoryService(categoryRepo)
+	catSvc := NewCategoryService(categoryRepo, nil, nil)
 	return &ProductService{
 		productRepo:      productRepo,
 		categoryRepo:     categoryRepo,
 		categorySvc:      catSvc,
 		breakingNewsRepo: breakingNewsRepo,
+		changeRepo:       changeRepo,
+		attributeSvc:     attributeSvc,
+		policy:           policy,
+		autocomplete:     autocomplete,
+		events:           events,
 	}
 }
 
+func (s *ProductService) publish(action string, p *models.Product) {
+	s.events.Publish(models.EventProduct, models.ChangeEvent{Action: action, ID: p.ID, Kind: p.Kind})
+}
+
 var slugRegexNonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
 
 func slugify(title string) string {
@@ -62,19 +83,52 @@ func (s *ProductService) generateUniqueSlug(kind models.ContentKind, title strin
 	}
 }
 
-func (s *ProductService) List(kind models.ContentKind, q string, categoryID *int64) ([]*models.Product, error) {
-	list, err := s.productRepo.List(kind, q, categoryID, 0, 0)
+var productSorts = []string{
+	models.SortRelevance, models.SortTitle, models.SortUpdatedAt, models.SortCreatedAt, models.SortPopularity,
+}
+
+// List = satu halaman product/script + total
+func (s *ProductService) List(f models.ProductFilter, page models.PageRequest) (*models.Page[*models.Product], error) {
+	if page.Sort == models.SortRelevance && f.Q == "" {
+		page.Sort = models.SortTitle
+	}
+	if err := checkSort(page, productSorts...); err != nil {
+		return nil, err
+	}
+	if err := s.attributeSvc.ValidateFilters(f.Attributes); err != nil {
+		return nil, err
+	}
+
+	total, err := s.productRepo.Count(f)
+	if err != nil {
+		return nil, err
+	}
+	f.Sort, f.Desc = page.Sort, page.Desc
+	f.Limit, f.Offset = page.PageSize, page.Offset()
+	list, err := s.productRepo.List(f)
 	if err != nil {
 		return nil, err
 	}
-	// enrich category_path (simple, OK untuk sekarang)
+	if err := s.fillCategoryPaths(list); err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
+}
+
+// fillCategoryPaths mengisi category_path semua product dengan 1 query
+func (s *ProductService) fillCategoryPaths(list []*models.Product) error {
+	ids := make([]int64, 0, len(list))
 	for _, p := range list {
-		path, err := s.categorySvc.BuildPathString(p.CategoryID)
-		if err == nil {
-			p.CategoryPath = path
-		}
+		ids = append(ids, p.CategoryID)
+	}
+	paths, err := s.categorySvc.Paths(ids)
+	if err != nil {
+		return err
 	}
-	return list, nil
+	for _, p := range list {
+		p.CategoryPath = paths[p.CategoryID]
+	}
+	return nil
 }
 
 func (s *ProductService) GetByID(id int64) (*models.Product, error) {
@@ -89,11 +143,19 @@ func (s *ProductService) GetByID(id int64) (*models.Product, error) {
 	return p, nil
 }
 
-func (s *ProductService) GetBySlug(kind models.ContentKind, slug string) (*models.Product, error) {
+// GetBySlug: product bertarget tim lain dianggap tidak ada (sql.ErrNoRows)
+func (s *ProductService) GetBySlug(kind models.ContentKind, slug string, aud models.Audience) (*models.Product, error) {
 	p, err := s.productRepo.GetBySlug(kind, slug)
 	if err != nil {
 		return nil, err
 	}
+	ok, err := s.productRepo.Visible(p.ID, aud)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
 	path, err := s.categorySvc.BuildPathString(p.CategoryID)
 	if err == nil {
 		p.CategoryPath = path
@@ -106,9 +168,15 @@ func (s *ProductService) Create(
 	title string,
 	categoryID int64,
 	blocks []models.ContentBlock,
+	attributes map[string]any,
 	isBreaking bool,
 	breakingTitle string,
 ) (int64, string, error) {
+	blocks, err := s.policy.ValidateContent(title, blocks)
+	if err != nil {
+		return 0, "", err
+	}
+
 	cat, err := s.categoryRepo.GetByID(categoryID)
 	if err != nil {
 		return 0, "", err
@@ -116,6 +184,10 @@ func (s *ProductService) Create(
 	if cat.Kind != kind {
 		return 0, "", fmt.Errorf("kategori tidak sesuai dengan jenis (product/script)")
 	}
+	attributes, err = s.attributeSvc.ValidateValues(categoryID, attributes)
+	if err != nil {
+		return 0, "", err
+	}
 
 	slug := s.generateUniqueSlug(kind, title, nil)
 
@@ -125,11 +197,18 @@ func (s *ProductService) Create(
 		Title:      strings.TrimSpace(title),
 		CategoryID: categoryID,
 		Blocks:     blocks,
+		Attributes: attributes,
+	}
+	if err := s.fillSearchText(p); err != nil {
+		return 0, "", err
 	}
 	id, err := s.productRepo.Create(p)
 	if err != nil {
 		return 0, "", err
 	}
+	p.ID = id
+	s.autocomplete.MarkDirty()
+	s.publish(models.ActionCreated, p)
 
 	if isBreaking {
 		t := strings.TrimSpace(breakingTitle)
@@ -138,29 +217,77 @@ func (s *ProductService) Create(
 		}
 		b := &models.BreakingNews{
 			Kind:      kind,
-			ProductID: id,
+			ProductID: &id,
 			Title:     t,
 			IsActive:  true,
 		}
-		_, _ = s.breakingNewsRepo.Create(b)
+		if bid, err := s.breakingNewsRepo.Create(b); err == nil {
+			b.ID = bid
+			s.events.Publish(models.EventBreakingNews, breakingNewsEvent(models.ActionCreated, b))
+		}
 	}
 
 	return id, slug, nil
 }
 
+// UpdateNotice = pemberitahuan "konten diperbarui" di ticker saat Update,
+// lengkap dengan ringkasan blok yang berubah
+type UpdateNotice struct {
+	Title    string // kosong = "Update: <judul product>"
+	Severity string // kosong = info
+	UserID   int64  // admin yang mengubah
+}
+
+// UpdateResult = hasil Update. NoticeID / NoticeError hanya terisi kalau
+// notice diminta; konten tetap tersimpan walau notice gagal dibuat.
+type UpdateResult struct {
+	Slug        string
+	NoticeID    int64
+	NoticeError string
+}
+
+// Update; notice != nil -> breaking news update + snapshot diff dibuat
 func (s *ProductService) Update(
 	id int64,
 	kind models.ContentKind,
 	title string,
 	categoryID int64,
 	blocks []models.ContentBlock,
-) (string, error) {
+	attributes map[string]any,
+	notice *UpdateNotice,
+) (*UpdateResult, error) {
+	blocks, err := s.policy.ValidateContent(title, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if notice != nil {
+		if notice.Severity == "" {
+			notice.Severity = models.SeverityInfo
+		}
+		switch notice.Severity {
+		case models.SeverityInfo, models.SeverityWarning, models.SeverityCritical:
+		default:
+			verr := &ValidationError{}
+			verr.add("noticeSeverity", "must be one of: info, warning, critical")
+			return nil, verr
+		}
+	}
+
 	cat, err := s.categoryRepo.GetByID(categoryID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if cat.Kind != kind {
-		return "", fmt.Errorf("kategori tidak sesuai dengan jenis (product/script)")
+		return nil, fmt.Errorf("kategori tidak sesuai dengan jenis (product/script)")
+	}
+	cur, curErr := s.productRepo.GetByID(id)
+	// attributes tidak dikirim -> pertahankan nilai lama
+	if attributes == nil && curErr == nil {
+		attributes = cur.Attributes
+	}
+	attributes, err = s.attributeSvc.ValidateValues(categoryID, attributes)
+	if err != nil {
+		return nil, err
 	}
 
 	self := id
@@ -173,24 +300,166 @@ func (s *ProductService) Update(
 		Title:      strings.TrimSpace(title),
 		CategoryID: categoryID,
 		Blocks:     blocks,
+		Attributes: attributes,
+	}
+	if err := s.fillSearchText(p); err != nil {
+		return nil, err
 	}
 	if err := s.productRepo.Update(p); err != nil {
-		return "", err
+		return nil, err
 	}
-	return slug, nil
+	s.autocomplete.MarkDirty()
+	s.publish(models.ActionUpdated, p)
+
+	res := &UpdateResult{Slug: slug}
+	if notice != nil {
+		if curErr != nil {
+			err = curErr
+		} else {
+			res.NoticeID, err = s.raiseUpdateNotice(cur, p, notice)
+		}
+		if err != nil {
+			log.Printf("update notice product %d: %v", id, err)
+			res.NoticeError = err.Error()
+		}
+	}
+	return res, nil
 }
 
-func (s *ProductService) Delete(id int64) error {
-	return s.productRepo.Delete(id)
+// raiseUpdateNotice menyimpan snapshot sebelum/sesudah lalu membuat breaking
+// news yang membawa ringkasannya; mengembalikan id breaking news-nya.
+func (s *ProductService) raiseUpdateNotice(before, after *models.Product, notice *UpdateNotice) (int64, error) {
+	change := &models.ContentChange{
+		ProductID:    after.ID,
+		TitleBefore:  before.Title,
+		TitleAfter:   after.Title,
+		BlocksBefore: before.Blocks,
+		BlocksAfter:  after.Blocks,
+		Summary:      summarizeChange(before.Title, after.Title, diffBlocks(before.Blocks, after.Blocks)),
+	}
+	if notice.UserID != 0 {
+		uid := notice.UserID
+		change.CreatedBy = &uid
+	}
+	changeID, err := s.changeRepo.Create(change)
+	if err != nil {
+		return 0, err
+	}
+
+	t := strings.TrimSpace(notice.Title)
+	if t == "" {
+		t = "Update: " + after.Title
+	}
+	b := &models.BreakingNews{
+		Kind:      after.Kind,
+		ProductID: &after.ID,
+		Title:     t,
+		IsActive:  true,
+		Severity:  notice.Severity,
+		ChangeID:  &changeID,
+	}
+	bid, err := s.breakingNewsRepo.Create(b)
+	if err != nil {
+		return 0, err
+	}
+	b.ID = bid
+	s.events.Publish(models.EventBreakingNews, breakingNewsEvent(models.ActionCreated, b))
+	return bid, nil
 }
 
-// Breaking News
-func (s *ProductService) ListActiveBreakingNews() ([]*models.BreakingNews, error) {
-	return s.breakingNewsRepo.ListActive()
+// GetChange = snapshot update + diff per blok (untuk link dari ticker).
+// Product bertarget tim lain dianggap tidak ada.
+func (s *ProductService) GetChange(id int64, aud models.Audience) (*models.ContentChange, error) {
+	c, err := s.changeRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.productRepo.Visible(c.ProductID, aud)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	// product yang sudah diarsip: diff tetap bisa dibaca, tanpa link
+	if p, err := s.productRepo.GetByID(c.ProductID); err == nil {
+		c.Product = &models.SearchTarget{Type: string(p.Kind), ID: p.ID, Slug: p.Slug, Kind: p.Kind}
+	}
+	c.Diff = diffBlocks(c.BlocksBefore, c.BlocksAfter)
+	return c, nil
 }
-func (s *ProductService) ListAllBreakingNews() ([]*models.BreakingNews, error) {
-	return s.breakingNewsRepo.ListAll()
+
+// fillSearchText mengisi teks sumber index full-text search sebelum product
+// disimpan (ditulis repository di transaksi yang sama dengan product-nya)
+func (s *ProductService) fillSearchText(p *models.Product) error {
+	path, err := s.categorySvc.BuildPathString(p.CategoryID)
+	if err != nil {
+		return err
+	}
+	p.SearchBody = blocksPlainText(p.Blocks)
+	p.SearchPath = path
+	return nil
 }
-func (s *ProductService) DeleteBreakingNews(id int64) error {
-	return s.breakingNewsRepo.Delete(id)
+
+func blocksPlainText(blocks []models.ContentBlock) string {
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == models.ContentTypeText && b.Text != nil {
+			parts = append(parts, stripHTML(*b.Text))
+		}
+		if b.Type == models.ContentTypeImage && b.AltText != nil {
+			parts = append(parts, *b.AltText)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Delete ikut menghapus breaking news product, jadi ditolak kalau ada yang
+// sudah di-acknowledge (catatan acknowledgement harus tetap ada)
+func (s *ProductService) Delete(id int64) error {
+	acked, err := s.breakingNewsRepo.ProductHasAcks(id)
+	if err != nil {
+		return err
+	}
+	if acked {
+		verr := &ValidationError{}
+		verr.add("id", "product punya breaking news yang sudah di-acknowledge agent, tidak bisa dihapus")
+		return verr
+	}
+	if err := s.productRepo.Delete(id); err != nil {
+		return err
+	}
+	s.autocomplete.MarkDirty()
+	s.publish(models.ActionDeleted, &models.Product{ID: id})
+	return nil
+}
+
+// Restore memulihkan product/script yang ikut diarsip bersama kategorinya
+func (s *ProductService) Restore(id int64) error {
+	err := s.productRepo.Restore(id)
+	switch {
+	case errors.Is(err, repository.ErrProductNotArchived),
+		errors.Is(err, repository.ErrProductCategoryArchived),
+		errors.Is(err, repository.ErrProductSlugTaken):
+		verr := &ValidationError{}
+		verr.add("id", "%s", err.Error())
+		return verr
+	case err != nil:
+		return err
+	}
+	s.autocomplete.MarkDirty()
+	if p, err := s.productRepo.GetByID(id); err == nil {
+		s.publish(models.ActionCreated, p)
+	}
+	return nil
+}
+
+// Facets menghitung facet atribut dari semua hasil filter (bukan cuma 1 halaman).
+// Agregasi dilakukan di database supaya tidak perlu memuat semua product.
+func (s *ProductService) Facets(f models.ProductFilter) ([]*models.Facet, error) {
+	stats, err := s.productRepo.AttributeStats(f)
+	if err != nil {
+		return nil, err
+	}
+	return s.attributeSvc.BuildFacets(stats)
 }
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var ErrRateNotFound = errors.New("rate tidak ditemukan untuk tanggal tersebut")
+
+type RateService struct {
+	repo        repository.RateRepository
+	productRepo repository.ProductRepository
+}
+
+func NewRateService(r repository.RateRepository, productRepo repository.ProductRepository) *RateService {
+	return &RateService{repo: r, productRepo: productRepo}
+}
+
+// checkVisible: rate product bertarget tim lain dianggap tidak ada
+func (s *RateService) checkVisible(productID int64, aud models.Audience) error {
+	ok, err := s.productRepo.Visible(productID, aud)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// List semua tabel rate product + nilai yang berlaku hari ini
+func (s *RateService) List(productID int64, aud models.Audience) ([]*models.RateTable, error) {
+	if err := s.checkVisible(productID, aud); err != nil {
+		return nil, err
+	}
+	tables, err := s.repo.ListTables(productID)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format(dateLayout)
+	for _, t := range tables {
+		if v, err := s.repo.ValueAsOf(productID, t.Key, today); err == nil {
+			t.Current = v
+		}
+	}
+	return tables, nil
+}
+
+// AsOf mengembalikan nilai yang berlaku pada tanggal tertentu (default hari ini)
+func (s *RateService) AsOf(productID int64, key, date string, aud models.Audience) (*models.RateValue, error) {
+	if err := s.checkVisible(productID, aud); err != nil {
+		return nil, err
+	}
+	if date == "" {
+		date = time.Now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return nil, fmt.Errorf("date harus format YYYY-MM-DD")
+	}
+	v, err := s.repo.ValueAsOf(productID, key, date)
+	if err == sql.ErrNoRows {
+		return nil, ErrRateNotFound
+	}
+	return v, err
+}
+
+// History = tabel + semua baris nilainya (terbaru di atas)
+func (s *RateService) History(productID int64, key string, aud models.Audience) (*models.RateTable, error) {
+	if err := s.checkVisible(productID, aud); err != nil {
+		return nil, err
+	}
+	t, err := s.repo.GetTable(productID, key)
+	if err != nil {
+		return nil, err
+	}
+	values, err := s.repo.ListValues(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Values = values
+	return t, nil
+}
+
+// Save mengganti isi tabel rate setelah validasi tanggal & overlap periode
+func (s *RateService) Save(t *models.RateTable, values []models.RateValue) (int64, error) {
+	if _, err := s.productRepo.GetByID(t.ProductID); err != nil {
+		return 0, fmt.Errorf("product tidak ditemukan")
+	}
+
+	for i := range values {
+		if values[i].ValidTo != nil && *values[i].ValidTo == "" {
+			values[i].ValidTo = nil
+		}
+	}
+
+	verr := &ValidationError{}
+	t.Label = strings.TrimSpace(t.Label)
+	if !attrKeyRegex.MatchString(t.Key) {
+		verr.add("key", "must be lowercase letters, digits or underscore, starting with a letter")
+	}
+	if t.Label == "" {
+		verr.add("label", "is required")
+	}
+	checkRatePeriods(verr, values)
+	if err := verr.orNil(); err != nil {
+		return 0, err
+	}
+	return s.repo.SaveTable(t, values)
+}
+
+func checkRatePeriods(verr *ValidationError, values []models.RateValue) {
+	type period struct {
+		idx      int
+		from, to time.Time
+		openEnd  bool
+	}
+	var periods []period
+
+	for i, v := range values {
+		field := fmt.Sprintf("values[%d]", i)
+		from, err := time.Parse(dateLayout, v.ValidFrom)
+		if err != nil {
+			verr.add(field+".valid_from", "must be a date (YYYY-MM-DD)")
+			continue
+		}
+		p := period{idx: i, from: from, openEnd: v.ValidTo == nil}
+		if !p.openEnd {
+			to, err := time.Parse(dateLayout, *v.ValidTo)
+			if err != nil {
+				verr.add(field+".valid_to", "must be a date (YYYY-MM-DD)")
+				continue
+			}
+			if to.Before(from) {
+				verr.add(field+".valid_to", "must not be before valid_from")
+				continue
+			}
+			p.to = to
+		}
+		periods = append(periods, p)
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].from.Before(periods[j].from) })
+	for i := 1; i < len(periods); i++ {
+		prev, cur := periods[i-1], periods[i]
+		if prev.openEnd || !prev.to.Before(cur.from) {
+			verr.add(fmt.Sprintf("values[%d].valid_from", cur.idx),
+				"overlaps with values[%d] (%s)", prev.idx, values[prev.idx].ValidFrom)
+		}
+	}
+}
+
+func (s *RateService) Delete(productID int64, key string) error {
+	return s.repo.DeleteTable(productID, key)
+}
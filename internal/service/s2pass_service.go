@@ -9,16 +9,18 @@ import (
 type S2Service struct {
 	repo        repository.S2NodeRepository
 	productRepo repository.ProductRepository
+	policy      *ContentPolicy
 }
 
 func NewS2Service(
 	repo repository.S2NodeRepository,
 	productRepo repository.ProductRepository,
+	policy *ContentPolicy,
 ) *S2Service {
-	return &S2Service{repo: repo, productRepo: productRepo}
+	return &S2Service{repo: repo, productRepo: productRepo, policy: policy}
 }
 
-func (s *S2Service) ListByParent(mainStr string, parentID *int64) ([]*models.S2Node, error) {
+func (s *S2Service) ListByParent(mainStr string, parentID *int64, aud models.Audience, page models.PageRequest) (*models.Page[*models.S2Node], error) {
 	var main models.S2MainType
 	switch mainStr {
 	case "call":
@@ -32,14 +34,62 @@ func (s *S2Service) ListByParent(mainStr string, parentID *int64) ([]*models.S2N
 	default:
 		return nil, fmt.Errorf("invalid main type")
 	}
-	return s.repo.ListByParent(main, parentID)
+	if err := checkSort(page, models.SortOrder, models.SortLabel, models.SortUpdatedAt); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.ListByParent(main, parentID, aud, page)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
+}
+
+// validateCalculatorStep: step kalkulator wajib link ke product yang ada
+func (s *S2Service) validateCalculatorStep(n *models.S2Node) error {
+	if n.StepKind == nil || *n.StepKind != models.S2StepCalc {
+		n.CalcPreset = nil
+		return nil
+	}
+	verr := &ValidationError{}
+	if n.LinkKind == nil || *n.LinkKind != models.S2LinkProduct {
+		verr.add("link_kind", "must be product for calculator step")
+	}
+	if n.LinkSlug == nil || *n.LinkSlug == "" {
+		verr.add("link_slug", "is required for calculator step")
+	} else if _, err := s.productRepo.GetBySlug(models.ContentKindProduct, *n.LinkSlug); err != nil {
+		verr.add("link_slug", "product not found")
+	}
+	if p := n.CalcPreset; p != nil {
+		if p.Principal != nil && *p.Principal <= 0 {
+			verr.add("calc_preset.principal", "must be greater than 0")
+		}
+		if p.TenorMonths != nil && (*p.TenorMonths <= 0 || *p.TenorMonths > maxTenorMonths) {
+			verr.add("calc_preset.tenor_months", "must be between 1 and %d", maxTenorMonths)
+		}
+		if p.Method != nil && *p.Method != models.LoanAnnuity && *p.Method != models.LoanFlat {
+			verr.add("calc_preset.method", "must be one of: annuity, flat")
+		}
+	}
+	return verr.orNil()
 }
 
 func (s *S2Service) CreateNode(n *models.S2Node) (int64, error) {
+	if err := s.policy.ValidateS2Node(n); err != nil {
+		return 0, err
+	}
+	if err := s.validateCalculatorStep(n); err != nil {
+		return 0, err
+	}
 	return s.repo.Create(n)
 }
 
 func (s *S2Service) UpdateNode(n *models.S2Node) error {
+	if err := s.policy.ValidateS2Node(n); err != nil {
+		return err
+	}
+	if err := s.validateCalculatorStep(n); err != nil {
+		return err
+	}
 	return s.repo.Update(n)
 }
 
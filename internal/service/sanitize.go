@@ -0,0 +1,227 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// tag yang boleh lolos + atribut yang diizinkan per tag.
+// Policy ini mengikuti output ReactQuill di admin editor.
+var allowedTags = map[string][]string{
+	"p":          nil,
+	"br":         nil,
+	"hr":         nil,
+	"div":        nil,
+	"span":       nil,
+	"strong":     nil,
+	"b":          nil,
+	"em":         nil,
+	"i":          nil,
+	"u":          nil,
+	"s":          nil,
+	"sub":        nil,
+	"sup":        nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"blockquote": nil,
+	"pre":        nil,
+	"code":       nil,
+	"ol":         nil,
+	"ul":         nil,
+	"li":         nil,
+	"table":      nil,
+	"thead":      nil,
+	"tbody":      nil,
+	"tr":         nil,
+	"th":         {"colspan", "rowspan"},
+	"td":         {"colspan", "rowspan"},
+	"a":          {"href", "title", "target"},
+	"img":        {"src", "alt", "width", "height"},
+}
+
+// tag yang dibuang beserta seluruh isinya (bukan cuma unwrap)
+var droppedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"frame":    true,
+	"frameset": true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+	"form":     true,
+	"textarea": true,
+	"select":   true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// tag block: saat diambil teks polosnya, dipisah spasi
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "blockquote": true, "pre": true,
+}
+
+// sanitizeHTML membersihkan HTML berdasarkan allowlist di atas.
+// Tag yang tidak dikenal di-unwrap (isi teksnya tetap), atribut di luar
+// allowlist dibuang, dan URL hanya boleh http/https/mailto/tel/relative.
+// imageAllowed dipakai untuk mengecek src <img>.
+func sanitizeHTML(s string, imageAllowed func(string) bool) string {
+	ctx := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), ctx)
+	if err != nil {
+		return html.EscapeString(s)
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		writeSanitized(&b, n, imageAllowed)
+	}
+	return b.String()
+}
+
+func writeSanitized(b *strings.Builder, n *html.Node, imageAllowed func(string) bool) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+		// lanjut di bawah
+	default:
+		// comment, doctype, dll dibuang
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+	if droppedTags[tag] {
+		return
+	}
+
+	attrs, ok := allowedTags[tag]
+	if !ok {
+		// unwrap: buang tag, pertahankan anak-anaknya
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSanitized(b, c, imageAllowed)
+		}
+		return
+	}
+
+	if tag == "img" && !imageAllowed(attrValue(n, "src")) {
+		return
+	}
+
+	b.WriteString("<" + tag)
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !attrAllowed(attrs, key) {
+			continue
+		}
+		val := a.Val
+		if key == "href" || key == "src" {
+			if !safeURL(val) {
+				continue
+			}
+		}
+		if key == "target" {
+			val = "_blank"
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+	if tag == "a" && attrValue(n, "target") != "" {
+		b.WriteString(` rel="noopener noreferrer"`)
+	}
+	// class cuma boleh class bawaan quill (ql-align-center, ql-indent-1, ...)
+	if cls := quillClasses(attrValue(n, "class")); cls != "" {
+		b.WriteString(` class="` + html.EscapeString(cls) + `"`)
+	}
+	b.WriteString(">")
+
+	if voidTags[tag] {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSanitized(b, c, imageAllowed)
+	}
+	b.WriteString("</" + tag + ">")
+}
+
+func attrAllowed(allowed []string, key string) bool {
+	for _, k := range allowed {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func quillClasses(class string) string {
+	var keep []string
+	for _, c := range strings.Fields(class) {
+		if strings.HasPrefix(c, "ql-") {
+			keep = append(keep, c)
+		}
+	}
+	return strings.Join(keep, " ")
+}
+
+func safeURL(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto", "tel":
+		return true
+	}
+	return false
+}
+
+// stripHTML mengambil teks polos dari HTML (dipakai untuk hitung panjang, dsb)
+func stripHTML(s string) string {
+	ctx := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), ctx)
+	if err != nil {
+		return s
+	}
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && droppedTags[strings.ToLower(n.Data)] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && blockTags[n.Data] {
+			b.WriteString(" ")
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,149 @@
+package service
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	p := NewContentPolicy("http://localhost:8080")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "script dibuang beserta isinya",
+			in:   `<p>halo<script>alert(1)</script></p>`,
+			want: `<p>halo</p>`,
+		},
+		{
+			name: "style & iframe dibuang",
+			in:   `<style>p{color:red}</style><iframe src="https://evil.test"></iframe><p>isi</p>`,
+			want: `<p>isi</p>`,
+		},
+		{
+			name: "href javascript ditolak",
+			in:   `<a href="javascript:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "href javascript huruf campur",
+			in:   `<a href="JaVaScRiPt:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "href javascript dengan spasi di depan",
+			in:   `<a href="  javascript:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "href javascript entity-encoded",
+			in:   `<a href="&#106;avascript&#58;alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "href javascript dengan tab di tengah",
+			in:   "<a href=\"java\tscript:alert(1)\">x</a>",
+			want: `<a>x</a>`,
+		},
+		{
+			name: "href data ditolak",
+			in:   `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "atribut on* dibuang",
+			in:   `<p onclick="alert(1)" onmouseover="alert(2)">x</p>`,
+			want: `<p>x</p>`,
+		},
+		{
+			name: "img onerror dibuang",
+			in:   `<img src="/static/a.png" onerror="alert(1)">`,
+			want: `<img src="/static/a.png">`,
+		},
+		{
+			name: "img dari luar upload store ditolak",
+			in:   `<p>a<img src="https://evil.test/a.png">b</p>`,
+			want: `<p>ab</p>`,
+		},
+		{
+			name: "img path traversal ditolak",
+			in:   `<img src="/static/../etc/passwd">`,
+			want: ``,
+		},
+		{
+			name: "img data ditolak",
+			in:   `<img src="data:image/png;base64,AAAA">`,
+			want: ``,
+		},
+		{
+			name: "img dari base url upload store",
+			in:   `<img src="http://localhost:8080/static/a.png" alt="Tabel">`,
+			want: `<img src="http://localhost:8080/static/a.png" alt="Tabel">`,
+		},
+		{
+			name: "class non ql- & style dibuang",
+			in:   `<p class="ql-align-center evil" style="position:fixed">x</p>`,
+			want: `<p class="ql-align-center">x</p>`,
+		},
+		{
+			name: "tag tidak dikenal di-unwrap",
+			in:   `<p><font color="red">merah</font></p>`,
+			want: `<p>merah</p>`,
+		},
+		{
+			name: "target link dipaksa _blank + rel",
+			in:   `<a href="https://bank.test" target="_self">x</a>`,
+			want: `<a href="https://bank.test" target="_blank" rel="noopener noreferrer">x</a>`,
+		},
+		{
+			name: "markup yang diizinkan tetap utuh",
+			in: `<h2>Syarat</h2><p class="ql-indent-1"><strong>Bunga</strong> <em>5%</em> &amp; <u>tetap</u></p>` +
+				`<ol><li>KTP</li><li>NPWP</li></ol>` +
+				`<table><tbody><tr><td colspan="2">Tenor</td></tr></tbody></table>` +
+				`<p><a href="mailto:cs@bank.test" title="Email">email</a><br></p>`,
+			want: `<h2>Syarat</h2><p class="ql-indent-1"><strong>Bunga</strong> <em>5%</em> &amp; <u>tetap</u></p>` +
+				`<ol><li>KTP</li><li>NPWP</li></ol>` +
+				`<table><tbody><tr><td colspan="2">Tenor</td></tr></tbody></table>` +
+				`<p><a href="mailto:cs@bank.test" title="Email">email</a><br></p>`,
+		},
+		{
+			name: "teks di-escape",
+			in:   `1 < 2 & "aman"`,
+			want: `1 &lt; 2 &amp; &#34;aman&#34;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.SanitizeHTML(tt.in); got != tt.want {
+				t.Errorf("SanitizeHTML(%q)\n got  %q\n want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://bank.test/kpr", true},
+		{"http://bank.test", true},
+		{"/products/kpr", true},
+		{"#bagian-2", true},
+		{"mailto:cs@bank.test", true},
+		{"tel:1500123", true},
+		{"", false},
+		{"   ", false},
+		{"javascript:alert(1)", false},
+		{"JAVASCRIPT:alert(1)", false},
+		{"vbscript:msgbox(1)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.url); got != tt.want {
+			t.Errorf("safeURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReportDays  = 30
+	defaultReportLimit = 100
+	maxReportLimit     = 1000
+)
+
+// Jenis laporan analytics search
+const (
+	ReportTopQueries  = "top"
+	ReportZeroResults = "zero-results"
+	ReportNoClick     = "no-click"
+)
+
+// LogQuery mencatat query agent. Gagal mencatat tidak boleh menggagalkan
+// search, jadi error cukup di-log dan id 0 dikembalikan.
+func (s *SearchService) LogQuery(userID int64, q, source string, resultCount int, fuzzy bool) int64 {
+	q = strings.TrimSpace(q)
+	norm := strings.Join(strings.Fields(strings.ToLower(q)), " ")
+	if norm == "" {
+		return 0
+	}
+	l := &models.SearchQueryLog{
+		Query:       q,
+		Normalized:  norm,
+		Source:      source,
+		ResultCount: resultCount,
+		Fuzzy:       fuzzy,
+	}
+	if userID != 0 {
+		l.UserID = &userID
+	}
+	id, err := s.logRepo.Create(l)
+	if err != nil {
+		log.Printf("search log: %v", err)
+		return 0
+	}
+	return id
+}
+
+// ResultCount = total hit di semua group
+func ResultCount(r *models.SearchResult) int {
+	return len(r.Products) + len(r.Scripts) + len(r.Categories) + len(r.S2Nodes)
+}
+
+func (s *SearchService) RecordClick(queryID, userID int64, targetType string, targetID int64) error {
+	switch targetType {
+	case models.SearchTypeProduct, models.SearchTypeScript, models.SearchTypeCategory, models.SearchTypeS2:
+	default:
+		return fmt.Errorf("type harus product, script, category atau s2")
+	}
+	return s.logRepo.RecordClick(queryID, userID, targetType, targetID)
+}
+
+// ReportRange mem-parse periode laporan (YYYY-MM-DD, default 30 hari terakhir)
+func ReportRange(from, to string, limit int) (models.SearchReportRange, error) {
+	rg := models.SearchReportRange{Limit: limit}
+	today, _ := time.Parse(dateLayout, time.Now().Format(dateLayout))
+
+	rg.To = today
+	if to != "" {
+		t, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return rg, fmt.Errorf("to harus format YYYY-MM-DD")
+		}
+		rg.To = t
+	}
+	rg.From = rg.To.AddDate(0, 0, -(defaultReportDays - 1))
+	if from != "" {
+		t, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return rg, fmt.Errorf("from harus format YYYY-MM-DD")
+		}
+		rg.From = t
+	}
+	if rg.From.After(rg.To) {
+		return rg, fmt.Errorf("from tidak boleh setelah to")
+	}
+
+	if rg.Limit <= 0 {
+		rg.Limit = defaultReportLimit
+	}
+	rg.Limit = min(rg.Limit, maxReportLimit)
+	return rg, nil
+}
+
+func (s *SearchService) QueryReport(report string, rg models.SearchReportRange) ([]*models.SearchQueryStat, error) {
+	var (
+		list []*models.SearchQueryStat
+		err  error
+	)
+	switch report {
+	case ReportTopQueries:
+		list, err = s.logRepo.TopQueries(rg)
+	case ReportZeroResults:
+		list, err = s.logRepo.ZeroResultQueries(rg)
+	case ReportNoClick:
+		list, err = s.logRepo.NoClickQueries(rg)
+	default:
+		return nil, fmt.Errorf("report tidak dikenal: %s", report)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []*models.SearchQueryStat{}
+	}
+	return list, nil
+}
+
+func (s *SearchService) Trend(rg models.SearchReportRange, interval string) ([]*models.SearchTrendPoint, error) {
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "week" {
+		return nil, fmt.Errorf("interval harus day atau week")
+	}
+	list, err := s.logRepo.Trend(rg, interval)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []*models.SearchTrendPoint{}
+	}
+	return list, nil
+}
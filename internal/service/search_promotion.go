@@ -0,0 +1,171 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"html"
+	"sort"
+	"strings"
+)
+
+const promotedSnippetWords = 25
+
+// applyPromotions menaruh hasil "best bet" yang cocok dengan query di paling
+// atas Products/Scripts (ditandai promoted) dan membuang duplikatnya dari
+// hasil organik. Filter type, kategori & target tim tetap dihormati.
+func (s *SearchService) applyPromotions(res *models.SearchResult, q string, opt models.SearchOptions, scope []int64) error {
+	norm := normalizePhrase(q)
+	if norm == "" {
+		return nil
+	}
+	promos, err := s.searchRepo.ListPromotions(true)
+	if err != nil {
+		return err
+	}
+
+	var matched []*models.SearchPromotion
+	for _, p := range promos {
+		if promotionMatches(p, norm) {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Position < matched[j].Position })
+
+	var products, scripts []*models.SearchHit
+	seen := map[int64]bool{}
+	for _, promo := range matched {
+		if seen[promo.ProductID] {
+			continue
+		}
+		seen[promo.ProductID] = true
+
+		p, err := s.productRepo.GetByID(promo.ProductID)
+		if err != nil {
+			continue
+		}
+		if !opt.Wants(string(p.Kind)) || (scope != nil && !containsID(scope, p.CategoryID)) {
+			continue
+		}
+		if ok, err := s.productRepo.Visible(p.ID, opt.Audience); err != nil || !ok {
+			continue
+		}
+		hit := &models.SearchHit{
+			Product:        p,
+			Snippet:        html.EscapeString(firstWords(blocksPlainText(p.Blocks), promotedSnippetWords)),
+			TitleHighlight: html.EscapeString(p.Title),
+			Promoted:       true,
+		}
+		if p.Kind == models.ContentKindScript {
+			scripts = append(scripts, hit)
+		} else {
+			products = append(products, hit)
+		}
+	}
+
+	res.Products = mergePromoted(s.withTargets(products), res.Products)
+	res.Scripts = mergePromoted(s.withTargets(scripts), res.Scripts)
+	return nil
+}
+
+// promotionMatches: norm = query yang sudah dinormalisasi
+func promotionMatches(p *models.SearchPromotion, norm string) bool {
+	switch p.MatchType {
+	case models.PromotionExact:
+		return norm == p.Pattern
+	case models.PromotionPrefix:
+		return strings.HasPrefix(norm, p.Pattern)
+	case models.PromotionContains:
+		return strings.Contains(" "+norm+" ", " "+p.Pattern+" ")
+	}
+	return false
+}
+
+func mergePromoted(promoted, organic []*models.SearchHit) []*models.SearchHit {
+	if len(promoted) == 0 {
+		return organic
+	}
+	ids := map[int64]bool{}
+	for _, h := range promoted {
+		ids[h.ID] = true
+	}
+	out := promoted
+	for _, h := range organic {
+		if !ids[h.ID] {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func containsID(list []int64, id int64) bool {
+	for _, x := range list {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func firstWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:n], " ") + " …"
+}
+
+// ===== Admin =====
+
+func (s *SearchService) ListPromotions() ([]*models.SearchPromotion, error) {
+	list, err := s.searchRepo.ListPromotions(false)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []*models.SearchPromotion{}
+	}
+	return list, nil
+}
+
+func (s *SearchService) validatePromotion(p *models.SearchPromotion) error {
+	verr := &ValidationError{}
+	p.Pattern = normalizePhrase(p.Pattern)
+	if p.Pattern == "" {
+		verr.add("pattern", "is required")
+	}
+	if p.MatchType == "" {
+		p.MatchType = models.PromotionExact
+	}
+	switch p.MatchType {
+	case models.PromotionExact, models.PromotionPrefix, models.PromotionContains:
+	default:
+		verr.add("match_type", "must be one of: exact, prefix, contains")
+	}
+	if _, err := s.productRepo.GetByID(p.ProductID); err != nil {
+		verr.add("product_id", "product/script %d not found", p.ProductID)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		verr.add("ends_at", "must be after starts_at")
+	}
+	return verr.orNil()
+}
+
+func (s *SearchService) CreatePromotion(p *models.SearchPromotion) (int64, error) {
+	if err := s.validatePromotion(p); err != nil {
+		return 0, err
+	}
+	return s.searchRepo.CreatePromotion(p)
+}
+
+func (s *SearchService) UpdatePromotion(p *models.SearchPromotion) error {
+	if err := s.validatePromotion(p); err != nil {
+		return err
+	}
+	return s.searchRepo.UpdatePromotion(p)
+}
+
+func (s *SearchService) DeletePromotion(id int64) error {
+	return s.searchRepo.DeletePromotion(id)
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	searchLimit      = 50
+	searchOtherLimit = 20 // kategori & node S2
+)
+
+type SearchService struct {
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	s2Repo       repository.S2NodeRepository
+	searchRepo   repository.SearchRepository
+	logRepo      repository.SearchLogRepository
+	categorySvc  *CategoryService
+	autocomplete *Autocompleter
+}
+
+func NewSearchService(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	s2Repo repository.S2NodeRepository,
+	searchRepo repository.SearchRepository,
+	logRepo repository.SearchLogRepository,
+	autocomplete *Autocompleter,
+) *SearchService {
+	return &SearchService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		s2Repo:       s2Repo,
+		searchRepo:   searchRepo,
+		logRepo:      logRepo,
+		categorySvc:  NewCategoryService(categoryRepo, nil, nil),
+		autocomplete: autocomplete,
+	}
+}
+
+// Search = unified search: products, scripts, kategori dan node S2PASS,
+// dikelompokkan per type dan masing-masing membawa target navigasi.
+func (s *SearchService) Search(q string, opt models.SearchOptions) (*models.SearchResult, error) {
+	q = strings.TrimSpace(q)
+
+	var scope []int64
+	if opt.CategoryID != nil {
+		ids, err := s.categoryRepo.DescendantIDs(*opt.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		scope = append([]int64{}, ids...) // non-nil walau kosong -> tetap difilter
+	}
+
+	res, err := s.searchContent(q, opt, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPromotions(res, q, opt, scope); err != nil {
+		return nil, err
+	}
+
+	res.Categories = []*models.CategoryHit{}
+	if opt.Wants(models.SearchTypeCategory) {
+		if res.Categories, err = s.searchCategories(q, scope, opt.Audience); err != nil {
+			return nil, err
+		}
+	}
+
+	res.S2Nodes = []*models.S2NodeHit{}
+	if opt.Wants(models.SearchTypeS2) && opt.CategoryID == nil {
+		if res.S2Nodes, err = s.searchS2(q); err != nil {
+			return nil, err
+		}
+	}
+
+	// hanya query yang langsung ada hasilnya (bukan typo) yang layak
+	// jadi saran autocomplete
+	if !res.Fuzzy && (!isEmptyResult(res) || len(res.Categories) > 0 || len(res.S2Nodes) > 0) {
+		s.autocomplete.RecordQuery(opt.UserID, q)
+	}
+	return res, nil
+}
+
+// Autocomplete = saran typeahead untuk input parsial milik userID
+func (s *SearchService) Autocomplete(q string, limit int, userID int64, aud models.Audience) (*models.AutocompleteResult, error) {
+	return s.autocomplete.Suggest(q, limit, userID, aud)
+}
+
+// searchContent: full-text search products & scripts, urut relevansi + snippet.
+// Query diperluas dulu dengan kamus sinonim. Kalau tidak ada hasil: coba
+// ejaan yang disarankan ("did you mean"), lalu fallback ke kemiripan
+// trigram di judul.
+func (s *SearchService) searchContent(q string, opt models.SearchOptions, scope []int64) (*models.SearchResult, error) {
+	res, err := s.searchFTS(q, opt, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !isEmptyResult(res) {
+		return res, nil
+	}
+
+	suggestion, err := s.suggest(q)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion != "" {
+		alt, err := s.searchFTS(suggestion, opt, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !isEmptyResult(alt) {
+			alt.DidYouMean = suggestion
+			alt.Fuzzy = true
+			return alt, nil
+		}
+	}
+
+	fuzzy, err := s.searchKinds(q, opt, scope, s.productRepo.SearchFuzzy)
+	if err != nil {
+		return nil, err
+	}
+	fuzzy.DidYouMean = suggestion
+	fuzzy.Fuzzy = !isEmptyResult(fuzzy)
+	return fuzzy, nil
+}
+
+func isEmptyResult(r *models.SearchResult) bool {
+	return len(r.Products) == 0 && len(r.Scripts) == 0
+}
+
+func (s *SearchService) searchFTS(q string, opt models.SearchOptions, scope []int64) (*models.SearchResult, error) {
+	exp, err := s.Expand(q)
+	if err != nil {
+		return nil, err
+	}
+	if exp.TSQuery == "" {
+		return &models.SearchResult{Products: []*models.SearchHit{}, Scripts: []*models.SearchHit{}}, nil
+	}
+	return s.searchKinds(exp.TSQuery, opt, scope, s.productRepo.Search)
+}
+
+func (s *SearchService) searchKinds(
+	q string,
+	opt models.SearchOptions,
+	scope []int64,
+	fn func(models.ContentKind, string, []int64, models.Audience, int) ([]*models.SearchHit, error),
+) (*models.SearchResult, error) {
+	res := &models.SearchResult{Products: []*models.SearchHit{}, Scripts: []*models.SearchHit{}}
+	if opt.Wants(models.SearchTypeProduct) {
+		products, err := fn(models.ContentKindProduct, q, scope, opt.Audience, searchLimit)
+		if err != nil {
+			return nil, err
+		}
+		res.Products = s.withTargets(products)
+	}
+	if opt.Wants(models.SearchTypeScript) {
+		scripts, err := fn(models.ContentKindScript, q, scope, opt.Audience, searchLimit)
+		if err != nil {
+			return nil, err
+		}
+		res.Scripts = s.withTargets(scripts)
+	}
+	return res, nil
+}
+
+func (s *SearchService) withTargets(hits []*models.SearchHit) []*models.SearchHit {
+	if hits == nil {
+		return []*models.SearchHit{}
+	}
+	ids := make([]int64, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.CategoryID)
+	}
+	paths, _ := s.categorySvc.Paths(ids) // path cuma pelengkap, gagal -> kosong
+	for _, h := range hits {
+		h.CategoryPath = paths[h.CategoryID]
+		h.Target = &models.SearchTarget{
+			Type: string(h.Kind),
+			ID:   h.ID,
+			Slug: h.Slug,
+			Kind: h.Kind,
+			Path: h.CategoryPath,
+		}
+	}
+	return hits
+}
+
+func (s *SearchService) searchCategories(q string, scope []int64, aud models.Audience) ([]*models.CategoryHit, error) {
+	hits, err := s.categoryRepo.Search(q, scope, aud, searchOtherLimit)
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		return []*models.CategoryHit{}, nil
+	}
+	ids := make([]int64, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	paths, _ := s.categorySvc.Paths(ids)
+	for _, h := range hits {
+		h.Path = paths[h.ID]
+		h.Target = &models.SearchTarget{
+			Type: models.SearchTypeCategory,
+			ID:   h.ID,
+			Kind: h.Kind,
+			Path: h.Path,
+		}
+	}
+	return hits, nil
+}
+
+func (s *SearchService) searchS2(q string) ([]*models.S2NodeHit, error) {
+	hits, err := s.s2Repo.Search(q, searchOtherLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return []*models.S2NodeHit{}, nil
+	}
+
+	ids := make([]int64, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	crumbs, err := s.s2Repo.Breadcrumbs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hits {
+		h.Breadcrumb = crumbs[h.ID]
+		h.Target = &models.SearchTarget{
+			Type:       models.SearchTypeS2,
+			ID:         h.ID,
+			MainType:   h.MainType,
+			Breadcrumb: h.Breadcrumb,
+		}
+	}
+	return hits, nil
+}
+
+var searchTokenRegex = regexp.MustCompile(`[\pL\pN]+`)
+
+// suggest memperbaiki tiap kata query dengan kata paling mirip dari kosakata.
+// Mengembalikan "" kalau tidak ada kata yang berubah.
+func (s *SearchService) suggest(q string) (string, error) {
+	words := searchTokenRegex.FindAllString(strings.ToLower(q), -1)
+	changed := false
+	for i, w := range words {
+		if len([]rune(w)) < 3 {
+			continue
+		}
+		alt, err := s.searchRepo.SuggestWord(w)
+		if err != nil {
+			return "", err
+		}
+		if alt != "" && alt != w {
+			words[i] = alt
+			changed = true
+		}
+	}
+	if !changed {
+		return "", nil
+	}
+	return strings.Join(words, " "), nil
+}
+
+// ===== Sinonim & singkatan =====
+
+// Expand memecah query jadi kata, mengganti kata/frasa yang ada di kamus
+// sinonim dengan semua alternatifnya, lalu menyusun tsquery:
+//
+//	"bunga kpr" -> bunga & (kpr | kredit <-> pemilikan <-> rumah)
+func (s *SearchService) Expand(q string) (*models.QueryExpansion, error) {
+	list, err := s.searchRepo.ListSynonyms()
+	if err != nil {
+		return nil, err
+	}
+	index, maxWords := buildSynonymIndex(list)
+
+	words := searchTokenRegex.FindAllString(strings.ToLower(q), -1)
+	exp := &models.QueryExpansion{Query: q, Groups: [][]string{}}
+	for i := 0; i < len(words); {
+		matched := false
+		// cocokkan frasa terpanjang dulu
+		for n := min(maxWords, len(words)-i); n >= 1; n-- {
+			if alts, ok := index[strings.Join(words[i:i+n], " ")]; ok {
+				exp.Groups = append(exp.Groups, alts)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			exp.Groups = append(exp.Groups, []string{words[i]})
+			i++
+		}
+	}
+
+	var groups []string
+	for _, alts := range exp.Groups {
+		var parts []string
+		for _, alt := range alts {
+			parts = append(parts, strings.Join(strings.Fields(alt), " <-> "))
+		}
+		if len(parts) == 1 {
+			groups = append(groups, parts[0])
+		} else {
+			groups = append(groups, "("+strings.Join(parts, " | ")+")")
+		}
+	}
+	exp.TSQuery = strings.Join(groups, " & ")
+	return exp, nil
+}
+
+// buildSynonymIndex: frasa (lowercase, dipisah spasi) -> semua alternatifnya
+func buildSynonymIndex(list []*models.Synonym) (map[string][]string, int) {
+	index := map[string][]string{}
+	maxWords := 1
+
+	add := func(key string, alts []string) {
+		for _, a := range alts {
+			if !containsString(index[key], a) {
+				index[key] = append(index[key], a)
+			}
+		}
+	}
+
+	for _, syn := range list {
+		alts := []string{normalizePhrase(syn.Term)}
+		for _, v := range syn.Synonyms {
+			if p := normalizePhrase(v); p != "" && !containsString(alts, p) {
+				alts = append(alts, p)
+			}
+		}
+		keys := alts[:1]
+		if syn.Bidirectional {
+			keys = alts
+		}
+		for _, k := range keys {
+			add(k, alts)
+			if n := len(strings.Fields(k)); n > maxWords {
+				maxWords = n
+			}
+		}
+	}
+	return index, maxWords
+}
+
+func normalizePhrase(s string) string {
+	return strings.Join(searchTokenRegex.FindAllString(strings.ToLower(s), -1), " ")
+}
+
+func containsString(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SearchService) ListSynonyms() ([]*models.Synonym, error) {
+	return s.searchRepo.ListSynonyms()
+}
+
+func (s *SearchService) validateSynonym(syn *models.Synonym) error {
+	verr := &ValidationError{}
+	syn.Term = normalizePhrase(syn.Term)
+	if syn.Term == "" {
+		verr.add("term", "is required")
+	}
+	var clean []string
+	for _, v := range syn.Synonyms {
+		if p := normalizePhrase(v); p != "" && p != syn.Term && !containsString(clean, p) {
+			clean = append(clean, p)
+		}
+	}
+	if len(clean) == 0 {
+		verr.add("synonyms", "must contain at least one synonym")
+	}
+	syn.Synonyms = clean
+	return verr.orNil()
+}
+
+func (s *SearchService) CreateSynonym(syn *models.Synonym) (int64, error) {
+	if err := s.validateSynonym(syn); err != nil {
+		return 0, err
+	}
+	return s.searchRepo.CreateSynonym(syn)
+}
+
+func (s *SearchService) UpdateSynonym(syn *models.Synonym) error {
+	if err := s.validateSynonym(syn); err != nil {
+		return err
+	}
+	return s.searchRepo.UpdateSynonym(syn)
+}
+
+func (s *SearchService) DeleteSynonym(id int64) error {
+	return s.searchRepo.DeleteSynonym(id)
+}
+
+// ===== Glossary (admin) =====
+
+func (s *SearchService) ListGlossary() ([]*models.GlossaryTerm, error) {
+	return s.searchRepo.ListGlossary()
+}
+
+func (s *SearchService) CreateGlossaryTerm(term string, description *string) (int64, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return 0, fmt.Errorf("term is required")
+	}
+	return s.searchRepo.CreateGlossaryTerm(&models.GlossaryTerm{Term: term, Description: description})
+}
+
+func (s *SearchService) DeleteGlossaryTerm(id int64) error {
+	return s.searchRepo.DeleteGlossaryTerm(id)
+}
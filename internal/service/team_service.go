@@ -0,0 +1,211 @@
+package service
+
+import (
+	"cc-helper-backend/internal/models"
+	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+const maxTeamName = 100
+
+var (
+	ErrTeamNotFound   = errors.New("tim tidak ditemukan")
+	ErrTargetNotFound = errors.New("konten tidak ditemukan")
+)
+
+// TeamService = tim agent + target tim pada breaking news, kategori & product
+type TeamService struct {
+	repo             repository.TeamRepository
+	userRepo         repository.UserRepository
+	breakingNewsRepo repository.BreakingNewsRepository
+	categoryRepo     repository.CategoryRepository
+	productRepo      repository.ProductRepository
+	autocomplete     *Autocompleter
+	events           *EventHub
+}
+
+func NewTeamService(
+	r repository.TeamRepository,
+	userRepo repository.UserRepository,
+	breakingNewsRepo repository.BreakingNewsRepository,
+	categoryRepo repository.CategoryRepository,
+	productRepo repository.ProductRepository,
+	autocomplete *Autocompleter,
+	events *EventHub,
+) *TeamService {
+	return &TeamService{
+		repo:             r,
+		userRepo:         userRepo,
+		breakingNewsRepo: breakingNewsRepo,
+		categoryRepo:     categoryRepo,
+		productRepo:      productRepo,
+		autocomplete:     autocomplete,
+		events:           events,
+	}
+}
+
+// teamError: sql.ErrNoRows -> ErrTeamNotFound, nama dobel -> ValidationError
+func teamError(err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrTeamNotFound
+	case errors.Is(err, repository.ErrTeamNameExists):
+		verr := &ValidationError{}
+		verr.add("name", "%s", err.Error())
+		return verr
+	}
+	return err
+}
+
+func validateTeamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	verr := &ValidationError{}
+	if name == "" {
+		verr.add("name", "is required")
+	} else if len([]rune(name)) > maxTeamName {
+		verr.add("name", "maksimal %d karakter", maxTeamName)
+	}
+	return name, verr.orNil()
+}
+
+// AudienceFor: admin & supervisor melihat semua konten, agent hanya konten
+// tanpa target atau yang ditarget ke salah satu timnya
+func (s *TeamService) AudienceFor(userID int64, role string) (models.Audience, error) {
+	if models.Role(role) == models.RoleAdmin || models.Role(role) == models.RoleSupervisor {
+		return models.AudienceAll, nil
+	}
+	ids, err := s.repo.UserTeamIDs(userID)
+	if err != nil {
+		return models.Audience{}, err
+	}
+	return models.Audience{TeamIDs: ids}, nil
+}
+
+func (s *TeamService) List() ([]*models.Team, error) {
+	return s.repo.List()
+}
+
+func (s *TeamService) Create(name string) (int64, error) {
+	name, err := validateTeamName(name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := s.repo.Create(&models.Team{Name: name})
+	if err != nil {
+		return 0, teamError(err)
+	}
+	return id, nil
+}
+
+func (s *TeamService) Rename(id int64, name string) error {
+	name, err := validateTeamName(name)
+	if err != nil {
+		return err
+	}
+	return teamError(s.repo.Update(&models.Team{ID: id, Name: name}))
+}
+
+// Delete ditolak selama tim masih jadi target konten; admin harus
+// mengganti target konten itu dulu supaya tidak ada yang terbuka diam-diam.
+func (s *TeamService) Delete(id int64) error {
+	targets, err := s.repo.TargetedBy(id)
+	if err != nil {
+		return err
+	}
+	verr := &ValidationError{}
+	for _, t := range targets {
+		verr.add("targets", "tim masih jadi target %s %d (%s)", t.Type, t.ID, t.Label)
+	}
+	if err := verr.orNil(); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	// user yang kehilangan tim perlu reload konten
+	s.events.Publish(models.EventResync, struct{}{})
+	return nil
+}
+
+// SetMembers mengganti anggota tim; semua user id harus ada
+func (s *TeamService) SetMembers(id int64, userIDs []int64) error {
+	verr := &ValidationError{}
+	for _, uid := range userIDs {
+		if _, err := s.userRepo.GetByID(uid); err == sql.ErrNoRows {
+			verr.add("user_ids", "user %d tidak ditemukan", uid)
+		} else if err != nil {
+			return err
+		}
+	}
+	if err := verr.orNil(); err != nil {
+		return err
+	}
+	return teamError(s.repo.SetMembers(id, userIDs))
+}
+
+// checkTarget memastikan jenis target valid & kontennya ada
+func (s *TeamService) checkTarget(target string, id int64) error {
+	var err error
+	switch target {
+	case models.TargetBreakingNews:
+		_, err = s.breakingNewsRepo.GetByID(id)
+	case models.TargetCategory:
+		_, err = s.categoryRepo.GetByID(id)
+	case models.TargetProduct:
+		_, err = s.productRepo.GetByID(id)
+	default:
+		verr := &ValidationError{}
+		verr.add("type", "must be one of: %s, %s, %s", models.TargetBreakingNews, models.TargetCategory, models.TargetProduct)
+		return verr
+	}
+	if err == sql.ErrNoRows {
+		return ErrTargetNotFound
+	}
+	return err
+}
+
+// Targets = tim target satu konten (kosong = semua user)
+func (s *TeamService) Targets(target string, id int64) ([]int64, error) {
+	if err := s.checkTarget(target, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Targets(target, id)
+}
+
+// SetTargets mengganti tim target konten; teamIDs kosong = untuk semua user
+func (s *TeamService) SetTargets(target string, id int64, teamIDs []int64) error {
+	if err := s.checkTarget(target, id); err != nil {
+		return err
+	}
+	teams, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	known := make(map[int64]bool, len(teams))
+	for _, t := range teams {
+		known[t.ID] = true
+	}
+	verr := &ValidationError{}
+	for _, tid := range teamIDs {
+		if !known[tid] {
+			verr.add("team_ids", "tim %d tidak ditemukan", tid)
+		}
+	}
+	if err := verr.orNil(); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetTargets(target, id, teamIDs); err != nil {
+		return err
+	}
+	s.autocomplete.MarkDirty()
+	if target == models.TargetBreakingNews {
+		s.events.Publish(models.EventBreakingNews, models.ChangeEvent{Action: models.ActionUpdated, ID: id})
+	} else {
+		// kategori bisa menyembunyikan banyak product & breaking news-nya sekaligus
+		s.events.Publish(models.EventResync, struct{}{})
+	}
+	return nil
+}
@@ -3,24 +3,81 @@ package service
 import (
 	"cc-helper-backend/internal/models"
 	"cc-helper-backend/internal/repository"
+	"database/sql"
+	"errors"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	sessions repository.SessionRepository
+	events   *EventHub
 }
 
-func NewUserService(r repository.UserRepository) *UserService {
-	return &UserService{repo: r}
+func NewUserService(r repository.UserRepository, sessions repository.SessionRepository, events *EventHub) *UserService {
+	return &UserService{repo: r, sessions: sessions, events: events}
 }
 
-func (s *UserService) List() ([]*models.User, error) {
-	return s.repo.List()
+var ErrUserNotFound = errors.New("user tidak ditemukan")
+
+// ForceLogout mencabut semua sesi user (access & refresh token langsung
+// ditolak) lalu mengirim event logout ke semua koneksi /events miliknya.
+// Mengembalikan jumlah sesi yang dicabut.
+func (s *UserService) ForceLogout(id int64, reason string) (int64, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+	n, err := s.sessions.RevokeAll(id)
+	if err != nil {
+		return 0, err
+	}
+	if reason == "" {
+		reason = "sesi diakhiri oleh admin"
+	}
+	s.events.PublishTo(id, models.EventLogout, models.LogoutEvent{Reason: reason})
+	return n, nil
+}
+
+func (s *UserService) List(page models.PageRequest) (*models.Page[*models.User], error) {
+	if err := checkSort(page, models.SortID, models.SortUsername, models.SortName, models.SortCreatedAt); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.List(page)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewPage(list, total, page), nil
 }
 
 func (s *UserService) Create(username, name, password string, role models.Role) (int64, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	verr := &ValidationError{}
+	if username == "" {
+		verr.add("username", "is required")
+	}
+	if password == "" {
+		verr.add("password", "is required")
+	}
+	switch role {
+	case models.RoleAdmin, models.RoleSupervisor, models.RoleAgent:
+	default:
+		verr.add("role", "must be one of: admin, supervisor, agent")
+	}
+	if err := verr.orNil(); err != nil {
+		return 0, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		verr.add("password", "must be at most 72 bytes")
+		return 0, verr
+	}
+	if err != nil {
+		return 0, err
+	}
 	u := &models.User{
 		Username:     username,
 		Name:         name,
@@ -0,0 +1,26 @@
+-- 003_product_attributes.sql
+
+-- schema atribut per kategori (diwariskan ke sub-kategori)
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id            BIGSERIAL PRIMARY KEY,
+    category_id   BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    key           TEXT NOT NULL CHECK (key ~ '^[a-z][a-z0-9_]*$'),
+    label         TEXT NOT NULL,
+    type          TEXT NOT NULL CHECK (type IN ('number','percentage','currency','enum','date')),
+    unit          TEXT,
+    options       JSONB,  -- daftar pilihan untuk type=enum
+    is_filterable BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order    INT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS category_attributes_unique_key
+ON category_attributes(category_id, key);
+
+-- nilai atribut per product: {"interest_rate": 7.5, "tenor_max": 240, ...}
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_products_attributes_gin
+ON products USING GIN (attributes);
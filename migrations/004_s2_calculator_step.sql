@@ -0,0 +1,11 @@
+-- 004_s2_calculator_step.sql
+
+-- step_kind: tambah 'link' (sudah dipakai backend) dan 'calculator'
+ALTER TABLE s2_nodes DROP CONSTRAINT IF EXISTS s2_nodes_step_kind_check;
+ALTER TABLE s2_nodes
+ADD CONSTRAINT s2_nodes_step_kind_check
+CHECK (step_kind IN ('script','input','link','calculator'));
+
+-- preset kalkulator untuk step_kind=calculator: {"principal":..., "tenor_months":..., "method":"annuity"}
+ALTER TABLE s2_nodes
+ADD COLUMN IF NOT EXISTS calc_preset JSONB;
@@ -0,0 +1,31 @@
+-- 005_product_rate_tables.sql
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- tabel rate/biaya per product yang berlaku per periode (effective-dated)
+CREATE TABLE IF NOT EXISTS product_rate_tables (
+    id          BIGSERIAL PRIMARY KEY,
+    product_id  BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    key         TEXT NOT NULL CHECK (key ~ '^[a-z][a-z0-9_]*$'), -- contoh: interest_rate
+    label       TEXT NOT NULL,
+    unit        TEXT,                                            -- contoh: "% p.a."
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, key)
+);
+
+-- valid_to inklusif, NULL = berlaku sampai ada perubahan berikutnya
+CREATE TABLE IF NOT EXISTS product_rate_values (
+    id          BIGSERIAL PRIMARY KEY,
+    table_id    BIGINT NOT NULL REFERENCES product_rate_tables(id) ON DELETE CASCADE,
+    value       NUMERIC NOT NULL,
+    valid_from  DATE NOT NULL,
+    valid_to    DATE,
+    note        TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (valid_to IS NULL OR valid_to >= valid_from),
+    EXCLUDE USING gist (table_id WITH =, daterange(valid_from, valid_to, '[]') WITH &&)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_rate_values_table_from
+ON product_rate_values(table_id, valid_from);
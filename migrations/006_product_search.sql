@@ -0,0 +1,52 @@
+-- 006_product_search.sql
+-- full-text search products/scripts: title (A), teks blocks (B), path kategori (C)
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Postgres belum punya stemmer bahasa Indonesia, jadi pakai parser simple
+-- (tanpa stemming, tanpa stopword bahasa Inggris) + unaccent
+DO $$ BEGIN
+    CREATE TEXT SEARCH CONFIGURATION indonesian (COPY = simple);
+EXCEPTION WHEN duplicate_object OR unique_violation THEN null;
+END $$;
+
+ALTER TEXT SEARCH CONFIGURATION indonesian
+    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, word, hword, hword_part
+    WITH unaccent, simple;
+
+-- diisi backend setiap create/update (teks polos dari text blocks & path kategori)
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS search_body TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS search_path TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('indonesian'::regconfig, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('indonesian'::regconfig, search_body), 'B') ||
+    setweight(to_tsvector('indonesian'::regconfig, search_path), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector
+ON products USING GIN (search_vector);
+
+-- backfill data lama
+UPDATE products p
+SET search_body = coalesce((
+    SELECT string_agg(regexp_replace(b->>'text', '<[^>]*>', ' ', 'g'), ' ')
+    FROM jsonb_array_elements(p.blocks) b
+    WHERE b->>'type' = 'text'
+), '');
+
+WITH RECURSIVE tree AS (
+    SELECT id, name::text AS path
+    FROM categories
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, tree.path || ' / ' || c.name
+    FROM categories c
+    JOIN tree ON c.parent_id = tree.id
+)
+UPDATE products p
+SET search_path = tree.path
+FROM tree
+WHERE tree.id = p.category_id;
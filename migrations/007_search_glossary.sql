@@ -0,0 +1,15 @@
+-- 007_search_glossary.sql
+
+-- istilah resmi (nama produk, singkatan, dll) untuk saran "did you mean"
+CREATE TABLE IF NOT EXISTS search_glossary (
+    id          BIGSERIAL PRIMARY KEY,
+    term        TEXT NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS search_glossary_term_unique
+ON search_glossary(lower(term));
+
+CREATE INDEX IF NOT EXISTS idx_search_glossary_term_trgm
+ON search_glossary USING GIN (lower(term) gin_trgm_ops);
@@ -0,0 +1,16 @@
+-- 008_search_synonyms.sql
+
+-- kamus sinonim/singkatan untuk ekspansi query search
+--   bidirectional = TRUE : term & semua synonyms saling menggantikan
+--   bidirectional = FALSE: hanya term -> synonyms (one-way)
+CREATE TABLE IF NOT EXISTS search_synonyms (
+    id            BIGSERIAL PRIMARY KEY,
+    term          TEXT NOT NULL,
+    synonyms      TEXT[] NOT NULL,
+    bidirectional BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS search_synonyms_term_unique
+ON search_synonyms(lower(term));
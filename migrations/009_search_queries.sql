@@ -0,0 +1,26 @@
+-- 009_search_queries.sql
+
+-- log setiap query search agent (GET /search & list ?q=) untuk laporan
+-- query populer / tanpa hasil / tanpa klik
+CREATE TABLE IF NOT EXISTS search_queries (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      INT REFERENCES users(id) ON DELETE SET NULL,
+    query        TEXT NOT NULL,
+    normalized   TEXT NOT NULL, -- lowercase, spasi dirapikan (kunci agregasi)
+    source       TEXT NOT NULL CHECK (source IN ('search','products','scripts')),
+    result_count INT NOT NULL,
+    fuzzy        BOOLEAN NOT NULL DEFAULT FALSE,
+
+    -- hasil yang diklik (terakhir)
+    clicked_type TEXT CHECK (clicked_type IN ('product','script','category','s2')),
+    clicked_id   BIGINT,
+    clicked_at   TIMESTAMPTZ,
+
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_queries_created
+ON search_queries(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_search_queries_normalized
+ON search_queries(normalized, created_at);
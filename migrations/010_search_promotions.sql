@@ -0,0 +1,24 @@
+-- 010_search_promotions.sql
+
+-- "best bets": product/script yang dipaksa tampil paling atas untuk query
+-- tertentu (mis. saat insiden), terlepas dari ranking.
+--   match_type exact    : query == pattern
+--   match_type prefix   : query diawali pattern (per karakter)
+--   match_type contains : pattern muncul sebagai frasa di query
+-- pattern disimpan ternormalisasi (lowercase, kata dipisah 1 spasi).
+CREATE TABLE IF NOT EXISTS search_promotions (
+    id         BIGSERIAL PRIMARY KEY,
+    pattern    TEXT NOT NULL,
+    match_type TEXT NOT NULL DEFAULT 'exact' CHECK (match_type IN ('exact','prefix','contains')),
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position   INT NOT NULL DEFAULT 0, -- urutan di antara hasil promoted (kecil = atas)
+    note       TEXT,
+    starts_at  TIMESTAMPTZ,            -- NULL = langsung berlaku
+    ends_at    TIMESTAMPTZ,            -- NULL = tanpa batas
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_promotions_window
+ON search_promotions(starts_at, ends_at);
@@ -0,0 +1,12 @@
+-- 011_list_sorting.sql
+
+-- sort "popularity" menghitung klik hasil search per product/script
+CREATE INDEX IF NOT EXISTS idx_search_queries_clicked
+ON search_queries(clicked_type, clicked_id, clicked_at)
+WHERE clicked_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_kind_updated
+ON products(kind, updated_at);
+
+CREATE INDEX IF NOT EXISTS idx_breaking_news_created
+ON breaking_news(created_at);
@@ -0,0 +1,19 @@
+-- 012_category_tree.sql
+
+-- urutan manual per level + metadata tampilan
+ALTER TABLE categories
+ADD COLUMN IF NOT EXISTS sort_order INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS description TEXT,
+ADD COLUMN IF NOT EXISTS icon TEXT;
+
+-- urutan awal = urutan lama (alfabetis) supaya tampilan tidak berubah
+UPDATE categories c
+SET sort_order = o.pos
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY kind, parent_id ORDER BY lower(name)) - 1 AS pos
+    FROM categories
+) o
+WHERE c.id = o.id;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_order
+ON categories(kind, parent_id, sort_order);
@@ -0,0 +1,25 @@
+-- 013_category_archive.sql
+
+-- hapus kategori dengan strategi "archive": kategori & konten di dalamnya
+-- disembunyikan (bukan dihapus) supaya masih bisa ditelusuri
+ALTER TABLE categories
+ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+ALTER TABLE products
+ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- nama kategori yang sudah diarsip boleh dipakai lagi di level yang sama
+DROP INDEX IF EXISTS categories_unique_sibling;
+CREATE UNIQUE INDEX categories_unique_sibling
+ON categories(kind, parent_id, lower(name))
+WHERE archived_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_active_category
+ON products(category_id)
+WHERE archived_at IS NULL;
+
+-- slug product yang diarsip juga boleh dipakai lagi
+DROP INDEX IF EXISTS products_kind_slug_unique;
+CREATE UNIQUE INDEX products_kind_slug_unique
+ON products(kind, slug)
+WHERE archived_at IS NULL;
@@ -0,0 +1,23 @@
+-- 014_breaking_news_schedule.sql
+
+-- jadwal tayang (NULL = tanpa batas) + tingkat kepentingan untuk urutan ticker
+ALTER TABLE breaking_news
+ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS severity TEXT NOT NULL DEFAULT 'info'
+    CHECK (severity IN ('info','warning','critical'));
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'breaking_news_schedule_check'
+    ) THEN
+        ALTER TABLE breaking_news
+        ADD CONSTRAINT breaking_news_schedule_check
+        CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_breaking_news_active_window
+ON breaking_news(starts_at, ends_at)
+WHERE is_active = TRUE;
@@ -0,0 +1,29 @@
+-- 015_announcements.sql
+
+-- pengumuman (kind = 'announcement') tidak terikat product: punya body
+-- sendiri (blocks seperti product) dan link opsional ke product/script/S2
+ALTER TABLE breaking_news
+DROP CONSTRAINT IF EXISTS breaking_news_kind_check;
+
+ALTER TABLE breaking_news
+ADD CONSTRAINT breaking_news_kind_check
+CHECK (kind IN ('product','script','announcement'));
+
+ALTER TABLE breaking_news
+ALTER COLUMN product_id DROP NOT NULL;
+
+ALTER TABLE breaking_news
+ADD COLUMN IF NOT EXISTS body JSONB,
+ADD COLUMN IF NOT EXISTS link_product_id BIGINT REFERENCES products(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS link_s2_node_id BIGINT REFERENCES s2_nodes(id) ON DELETE SET NULL;
+
+-- breaking news product/script tetap wajib punya product_id (hapus product = ikut terhapus),
+-- pengumuman tidak pakai product_id dan maksimal 1 link
+ALTER TABLE breaking_news
+DROP CONSTRAINT IF EXISTS breaking_news_target_check,
+ADD CONSTRAINT breaking_news_target_check
+CHECK (
+    (kind <> 'announcement' AND product_id IS NOT NULL)
+    OR (kind = 'announcement' AND product_id IS NULL
+        AND (link_product_id IS NULL OR link_s2_node_id IS NULL))
+);
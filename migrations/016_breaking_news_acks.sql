@@ -0,0 +1,23 @@
+-- 016_breaking_news_acks.sql
+
+-- supervisor: lihat laporan acknowledgement (tanpa akses admin)
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check;
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_check
+CHECK (role IN ('admin','supervisor','agent'));
+
+-- item wajib dibaca: tiap agent harus konfirmasi sudah membaca
+ALTER TABLE breaking_news
+ADD COLUMN IF NOT EXISTS is_mandatory BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS breaking_news_acks (
+    breaking_news_id BIGINT NOT NULL REFERENCES breaking_news(id) ON DELETE CASCADE,
+    user_id          INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    acknowledged_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (breaking_news_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_breaking_news_acks_user
+ON breaking_news_acks(user_id);
@@ -0,0 +1,38 @@
+-- 017_teams.sql
+
+-- tim agent (mis. kartu kredit, KPR). Konten tanpa target = semua user,
+-- konten bertarget hanya untuk anggota salah satu tim target.
+CREATE TABLE IF NOT EXISTS teams (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS teams_unique_name
+ON teams(lower(name));
+
+CREATE TABLE IF NOT EXISTS user_teams (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, team_id)
+);
+
+CREATE TABLE IF NOT EXISTS breaking_news_teams (
+    breaking_news_id BIGINT NOT NULL REFERENCES breaking_news(id) ON DELETE CASCADE,
+    team_id          BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (breaking_news_id, team_id)
+);
+
+-- target kategori berlaku juga untuk semua turunannya
+CREATE TABLE IF NOT EXISTS category_teams (
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    team_id     BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (category_id, team_id)
+);
+
+CREATE TABLE IF NOT EXISTS product_teams (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    team_id    BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, team_id)
+);
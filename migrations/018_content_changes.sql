@@ -0,0 +1,21 @@
+-- 018_content_changes.sql
+
+-- snapshot sebelum/sesudah update product yang diumumkan lewat breaking
+-- news ("konten diperbarui"), sumber tampilan diff untuk agent
+CREATE TABLE IF NOT EXISTS content_changes (
+    id            BIGSERIAL PRIMARY KEY,
+    product_id    BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    title_before  TEXT NOT NULL,
+    title_after   TEXT NOT NULL,
+    blocks_before JSONB NOT NULL DEFAULT '[]',
+    blocks_after  JSONB NOT NULL DEFAULT '[]',
+    summary       JSONB NOT NULL,
+    created_by    INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS content_changes_product_idx
+ON content_changes(product_id, created_at DESC);
+
+ALTER TABLE breaking_news
+ADD COLUMN IF NOT EXISTS change_id BIGINT REFERENCES content_changes(id) ON DELETE SET NULL;
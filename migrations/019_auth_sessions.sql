@@ -0,0 +1,27 @@
+-- 019_auth_sessions.sql
+
+-- satu baris per login. Access token (JWT pendek) membawa id sesi (sid)
+-- dan hanya berlaku selama sesinya belum dicabut. Refresh token disimpan
+-- sebagai hash sha256 & dirotasi setiap dipakai; previous_hash untuk
+-- mendeteksi refresh token lama yang dipakai ulang (sesi langsung dicabut).
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_hash  TEXT NOT NULL,
+    previous_hash TEXT,
+    user_agent    TEXT,
+    ip            TEXT,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS auth_sessions_refresh_hash
+ON auth_sessions(refresh_hash);
+
+CREATE INDEX IF NOT EXISTS auth_sessions_previous_hash
+ON auth_sessions(previous_hash) WHERE previous_hash IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS auth_sessions_user_active
+ON auth_sessions(user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,18 @@
+-- 020_team_targets_restrict.sql
+
+-- tim yang masih jadi target konten tidak boleh dihapus: kalau link target
+-- ikut terhapus, konten bertarget diam-diam terbuka untuk semua user
+ALTER TABLE breaking_news_teams
+DROP CONSTRAINT IF EXISTS breaking_news_teams_team_id_fkey,
+ADD CONSTRAINT breaking_news_teams_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE RESTRICT;
+
+ALTER TABLE category_teams
+DROP CONSTRAINT IF EXISTS category_teams_team_id_fkey,
+ADD CONSTRAINT category_teams_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE RESTRICT;
+
+ALTER TABLE product_teams
+DROP CONSTRAINT IF EXISTS product_teams_team_id_fkey,
+ADD CONSTRAINT product_teams_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE RESTRICT;
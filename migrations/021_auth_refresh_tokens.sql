@@ -0,0 +1,36 @@
+-- 021_auth_refresh_tokens.sql
+
+-- semua refresh token yang pernah dikeluarkan untuk satu sesi (token family).
+-- Token yang sudah dirotasi (rotated_at terisi) dipakai lagi = kemungkinan
+-- dicuri -> sesinya dicabut, berapa pun generasi token lamanya.
+CREATE TABLE IF NOT EXISTS auth_refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES auth_sessions(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS auth_refresh_tokens_session
+ON auth_refresh_tokens(session_id);
+
+-- pindahkan token dari kolom lama (019)
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'auth_sessions' AND column_name = 'refresh_hash'
+    ) THEN
+        INSERT INTO auth_refresh_tokens (token_hash, session_id, created_at)
+        SELECT refresh_hash, id, last_used_at FROM auth_sessions
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO auth_refresh_tokens (token_hash, session_id, created_at, rotated_at)
+        SELECT previous_hash, id, created_at, last_used_at FROM auth_sessions
+        WHERE previous_hash IS NOT NULL
+        ON CONFLICT DO NOTHING;
+    END IF;
+END $$;
+
+ALTER TABLE auth_sessions
+DROP COLUMN IF EXISTS refresh_hash,
+DROP COLUMN IF EXISTS previous_hash;
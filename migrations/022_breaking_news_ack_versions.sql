@@ -0,0 +1,25 @@
+-- 022_breaking_news_ack_versions.sql
+
+-- catatan acknowledgement = bukti agent sudah membaca, jadi tidak boleh
+-- ikut terhapus bersama item / user-nya
+ALTER TABLE breaking_news_acks
+DROP CONSTRAINT IF EXISTS breaking_news_acks_breaking_news_id_fkey,
+ADD CONSTRAINT breaking_news_acks_breaking_news_id_fkey
+    FOREIGN KEY (breaking_news_id) REFERENCES breaking_news(id) ON DELETE RESTRICT;
+
+ALTER TABLE breaking_news_acks
+DROP CONSTRAINT IF EXISTS breaking_news_acks_user_id_fkey,
+ADD CONSTRAINT breaking_news_acks_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- version naik tiap isi item (judul, body, link) diubah; acknowledge
+-- dicatat per versi sehingga item wajib yang diedit harus dibaca ulang
+ALTER TABLE breaking_news
+ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE breaking_news_acks
+ADD COLUMN IF NOT EXISTS item_version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE breaking_news_acks
+DROP CONSTRAINT IF EXISTS breaking_news_acks_pkey,
+ADD PRIMARY KEY (breaking_news_id, user_id, item_version);
@@ -0,0 +1,32 @@
+-- 023_search_vocabulary.sql
+
+-- kosakata "did you mean": kata judul product/script, istilah glossary &
+-- kamus sinonim. Diisi backend per sumber tiap data sumbernya berubah,
+-- supaya saran cukup lewat index trigram tanpa memecah semua judul per query.
+CREATE TABLE IF NOT EXISTS search_vocabulary (
+    source    TEXT   NOT NULL CHECK (source IN ('product', 'glossary', 'synonym')),
+    source_id BIGINT NOT NULL,
+    word      TEXT   NOT NULL,
+    PRIMARY KEY (source, source_id, word)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_vocabulary_word_trgm
+ON search_vocabulary USING GIN (word gin_trgm_ops);
+
+-- backfill data lama; kata dipecah per huruf/angka Unicode ([[:alnum:]]),
+-- sama dengan tokenizer query di backend ([\pL\pN]+)
+INSERT INTO search_vocabulary (source, source_id, word)
+SELECT DISTINCT 'product', p.id, w
+FROM products p, regexp_split_to_table(lower(p.title), '[^[:alnum:]]+') w
+WHERE length(w) >= 3 AND p.archived_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO search_vocabulary (source, source_id, word)
+SELECT 'glossary', id, lower(term) FROM search_glossary
+ON CONFLICT DO NOTHING;
+
+INSERT INTO search_vocabulary (source, source_id, word)
+SELECT 'synonym', id, lower(term) FROM search_synonyms
+UNION
+SELECT 'synonym', id, lower(unnest(synonyms)) FROM search_synonyms
+ON CONFLICT DO NOTHING;